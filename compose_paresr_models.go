@@ -2,47 +2,93 @@ package compose_parser
 
 import (
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // ComposeServiceConfig представляет конфигурацию одного сервиса в Docker Compose
 type ComposeServiceConfig struct {
 	// Основные параметры
-	Name       string       `json:"name"`
-	Image      string       `json:"image,omitempty"`
-	Build      *BuildConfig `json:"build,omitempty"`
-	Command    []string     `json:"command,omitempty"`
-	Entrypoint []string     `json:"entrypoint,omitempty"`
-	WorkingDir string       `json:"working_dir,omitempty"`
-	User       string       `json:"user,omitempty"`
-	Platform   string       `json:"platform,omitempty"`
-	Order      int          `json:"order,omitempty"` // Порядковый номер сервиса в файле
+	Name          string       `json:"name"`
+	Image         string       `json:"image,omitempty"`
+	Build         *BuildConfig `json:"build,omitempty"`
+	ContainerName string       `json:"container_name,omitempty"`
+	Command       []string     `json:"command,omitempty"`
+	Entrypoint    []string     `json:"entrypoint,omitempty"`
+	WorkingDir    string       `json:"working_dir,omitempty"`
+	User          string       `json:"user,omitempty"`
+	Platform      string       `json:"platform,omitempty"`
+	Profiles      []string     `json:"profiles,omitempty"` // Профили, в которых сервис включен
+	Order         int          `json:"order,omitempty"`    // Порядковый номер сервиса в файле
 
 	// Зависимости и перезапуск
-	DependsOn []string `json:"depends_on,omitempty"`
-	Restart   string   `json:"restart,omitempty"`
+	DependsOn []ServiceDependency `json:"depends_on,omitempty"`
+	Restart   string              `json:"restart,omitempty"`
 
 	// Сеть и порты
-	Ports       []PortMapping `json:"ports,omitempty"`
-	Expose      []string      `json:"expose,omitempty"`
-	Networks    []string      `json:"networks,omitempty"`
-	NetworkMode string        `json:"network_mode,omitempty"`
-
-	// Переменные окружения
-	Environment map[string]string `json:"environment,omitempty"`
-	EnvFile     []string          `json:"env_file,omitempty"`
+	Ports       []PortMapping          `json:"ports,omitempty"`
+	Expose      []string               `json:"expose,omitempty"`
+	Networks    []ServiceNetworkConfig `json:"networks,omitempty"`
+	NetworkMode string                 `json:"network_mode,omitempty"`
+	Hostname    string                 `json:"hostname,omitempty"`
+	Domainname  string                 `json:"domainname,omitempty"`
+	DNS         []string               `json:"dns,omitempty"`
+	DNSSearch   []string               `json:"dns_search,omitempty"`
+	DNSOpt      []string               `json:"dns_opt,omitempty"`
+	ExtraHosts  map[string][]string    `json:"extra_hosts,omitempty"` // Имя хоста -> IP адреса
+
+	// Переменные окружения с учетом env_file: значения environment имеют приоритет.
+	// Переменные без значения берутся из источника переменных парсера
+	Environment          map[string]string     `json:"environment,omitempty"`
+	EnvironmentVariables []EnvironmentVariable `json:"environment_variables,omitempty"` // Переменные environment в порядке объявления
+	EnvFile              []EnvFileConfig       `json:"env_file,omitempty"`
 
 	// Тома и монтирования
-	Volumes     []VolumeMount `json:"volumes,omitempty"`
-	VolumesFrom []string      `json:"volumes_from,omitempty"`
+	Volumes     []VolumeMount   `json:"volumes,omitempty"`
+	VolumesFrom []string        `json:"volumes_from,omitempty"`
+	Tmpfs       []string        `json:"tmpfs,omitempty"`
+	Devices     []DeviceMapping `json:"devices,omitempty"`
+
+	// Секреты и конфигурации, подключенные к сервису
+	Secrets []ServiceFileReference `json:"secrets,omitempty"`
+	Configs []ServiceFileReference `json:"configs,omitempty"`
 
 	// Ресурсы
-	Deploy     *DeployConfig `json:"deploy,omitempty"`
-	CPUShares  int64         `json:"cpu_shares,omitempty"`
-	CPUSet     string        `json:"cpuset,omitempty"`
-	CPUQuota   int64         `json:"cpu_quota,omitempty"`
-	CPUs       float64       `json:"cpus,omitempty"`
-	Memory     string        `json:"memory,omitempty"`
-	MemorySwap string        `json:"memory_swap,omitempty"`
+	Deploy         *DeployConfig            `json:"deploy,omitempty"`
+	CPUShares      int64                    `json:"cpu_shares,omitempty"`
+	CPUSet         string                   `json:"cpuset,omitempty"`
+	CPUQuota       int64                    `json:"cpu_quota,omitempty"`
+	CPUs           float64                  `json:"cpus,omitempty"`
+	Memory         string                   `json:"memory,omitempty"`      // Ключ memory вне спецификации, записывается как mem_limit
+	MemorySwap     string                   `json:"memory_swap,omitempty"` // memswap_limit или ключ memory_swap вне спецификации
+	MemLimit       string                   `json:"mem_limit,omitempty"`
+	MemReservation string                   `json:"mem_reservation,omitempty"`
+	ShmSize        string                   `json:"shm_size,omitempty"`
+	OomScoreAdj    int64                    `json:"oom_score_adj,omitempty"`
+	Ulimits        map[string]*UlimitConfig `json:"ulimits,omitempty"`
+	Sysctls        map[string]string        `json:"sysctls,omitempty"`
+
+	// Безопасность и пространства имен
+	Privileged  bool     `json:"privileged,omitempty"`
+	ReadOnly    bool     `json:"read_only,omitempty"`
+	CapAdd      []string `json:"cap_add,omitempty"`
+	CapDrop     []string `json:"cap_drop,omitempty"`
+	SecurityOpt []string `json:"security_opt,omitempty"`
+	GroupAdd    []string `json:"group_add,omitempty"`
+	Ipc         string   `json:"ipc,omitempty"`
+	Pid         string   `json:"pid,omitempty"`
+	UsernsMode  string   `json:"userns_mode,omitempty"`
+
+	// Запуск и остановка контейнера
+	Init            *bool   `json:"init,omitempty"` // nil, если не задано
+	Tty             bool    `json:"tty,omitempty"`
+	StdinOpen       bool    `json:"stdin_open,omitempty"`
+	StopSignal      string  `json:"stop_signal,omitempty"`
+	StopGracePeriod string  `json:"stop_grace_period,omitempty"`
+	PullPolicy      string  `json:"pull_policy,omitempty"`
+	Scale           *uint64 `json:"scale,omitempty"`  // nil, если не задано
+	Attach          *bool   `json:"attach,omitempty"` // nil, если не задано (логи сервиса выводятся)
+	Runtime         string  `json:"runtime,omitempty"`
 
 	// Логирование
 	Logging *LoggingConfig `json:"logging,omitempty"`
@@ -51,42 +97,195 @@ type ComposeServiceConfig struct {
 	HealthCheck *HealthCheckConfig `json:"healthcheck,omitempty"`
 
 	// Метки и расширения
-	Labels  map[string]string `json:"labels,omitempty"`
-	Extends *ExtendsConfig    `json:"extends,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty"`
+	Extends         *ExtendsConfig    `json:"extends,omitempty"`
+	InheritedFields map[string]string `json:"inherited_fields,omitempty"` // Поле -> базовый сервис, от которого оно унаследовано
+
+	// Поля расширений x-*
+	Extensions     map[string]interface{} `json:"extensions,omitempty"`
+	ExtensionNodes map[string]*yaml.Node  `json:"-"` // Исходные узлы YAML, заполняются с опцией WithExtensionNodes
+
+	// Положение в исходном файле
+	Location *SourceLocation `json:"location,omitempty"`
+
+	// Ошибки и предупреждения разбора, относящиеся к сервису
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
 
 	// Временные метки
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
 	// Статус
-	Status string `json:"status"` // saved, active, inactive
+	Status string `json:"status"` // parsed, error (разобран частично), saved, active, inactive
+}
+
+// Условия запуска зависимого сервиса
+const (
+	DependencyConditionStarted   = "service_started"
+	DependencyConditionHealthy   = "service_healthy"
+	DependencyConditionCompleted = "service_completed_successfully"
+)
+
+// ServiceDependency представляет зависимость сервиса от другого сервиса (depends_on)
+type ServiceDependency struct {
+	Service   string `json:"service"`
+	Condition string `json:"condition,omitempty"` // service_started, service_healthy, service_completed_successfully
+	Restart   bool   `json:"restart,omitempty"`   // Перезапускать сервис при обновлении зависимости
+	Required  bool   `json:"required"`            // Отсутствие необязательной зависимости не является ошибкой
+}
+
+// ServiceNetworkConfig представляет подключение сервиса к сети
+type ServiceNetworkConfig struct {
+	Name          string            `json:"name"`
+	Aliases       []string          `json:"aliases,omitempty"`
+	IPv4Address   string            `json:"ipv4_address,omitempty"`
+	IPv6Address   string            `json:"ipv6_address,omitempty"`
+	LinkLocalIPs  []string          `json:"link_local_ips,omitempty"`
+	MacAddress    string            `json:"mac_address,omitempty"`
+	DriverOpts    map[string]string `json:"driver_opts,omitempty"`
+	Priority      int               `json:"priority,omitempty"`    // Порядок подключения к сетям
+	GwPriority    int               `json:"gw_priority,omitempty"` // Приоритет выбора шлюза по умолчанию
+	InterfaceName string            `json:"interface_name,omitempty"`
+	Location      *SourceLocation   `json:"location,omitempty"` // Положение в исходном файле
 }
 
 // BuildConfig представляет конфигурацию сборки
 type BuildConfig struct {
-	Context    string            `json:"context"`
-	Dockerfile string            `json:"dockerfile,omitempty"`
-	Args       map[string]string `json:"args,omitempty"`
-	Target     string            `json:"target,omitempty"`
-	CacheFrom  []string          `json:"cache_from,omitempty"`
-	Labels     map[string]string `json:"labels,omitempty"`
+	Context            string                   `json:"context"`
+	Dockerfile         string                   `json:"dockerfile,omitempty"`
+	DockerfileInline   string                   `json:"dockerfile_inline,omitempty"`
+	Args               map[string]string        `json:"args,omitempty"`
+	Target             string                   `json:"target,omitempty"`
+	CacheFrom          []string                 `json:"cache_from,omitempty"`
+	CacheTo            []string                 `json:"cache_to,omitempty"`
+	NoCache            bool                     `json:"no_cache,omitempty"`
+	Pull               bool                     `json:"pull,omitempty"`
+	Labels             map[string]string        `json:"labels,omitempty"`
+	AdditionalContexts map[string]string        `json:"additional_contexts,omitempty"` // Имя контекста -> путь, URL или docker-image://
+	SSH                map[string]string        `json:"ssh,omitempty"`                 // ID -> путь к ключу или сокету, пусто для агента по умолчанию
+	Secrets            []ServiceFileReference   `json:"secrets,omitempty"`
+	Platforms          []string                 `json:"platforms,omitempty"`
+	Tags               []string                 `json:"tags,omitempty"`
+	Network            string                   `json:"network,omitempty"`
+	ShmSize            string                   `json:"shm_size,omitempty"`
+	ExtraHosts         map[string][]string      `json:"extra_hosts,omitempty"`
+	Isolation          string                   `json:"isolation,omitempty"`
+	Privileged         bool                     `json:"privileged,omitempty"`
+	Ulimits            map[string]*UlimitConfig `json:"ulimits,omitempty"`
+	Entitlements       []string                 `json:"entitlements,omitempty"`
 }
 
 // PortMapping представляет маппинг портов
 type PortMapping struct {
-	Target    uint16 `json:"target"`
-	Published uint16 `json:"published,omitempty"`
-	Protocol  string `json:"protocol,omitempty"`
-	Mode      string `json:"mode,omitempty"`
+	Name         string          `json:"name,omitempty"`
+	Target       uint16          `json:"target"`
+	Published    uint16          `json:"published,omitempty"`
+	PublishedEnd uint16          `json:"published_end,omitempty"` // Конец диапазона опубликованных портов, если задан диапазон
+	HostIP       string          `json:"host_ip,omitempty"`
+	Protocol     string          `json:"protocol,omitempty"`
+	AppProtocol  string          `json:"app_protocol,omitempty"`
+	Mode         string          `json:"mode,omitempty"`
+	Location     *SourceLocation `json:"location,omitempty"` // Положение в исходном файле
 }
 
 // VolumeMount представляет монтирование тома
 type VolumeMount struct {
-	Type        string `json:"type"` // bind, volume, tmpfs, npipe
-	Source      string `json:"source,omitempty"`
-	Target      string `json:"target"`
-	ReadOnly    bool   `json:"read_only,omitempty"`
-	Consistency string `json:"consistency,omitempty"`
+	Type        string               `json:"type"` // bind, volume, tmpfs, npipe, image, cluster
+	Source      string               `json:"source,omitempty"`
+	Target      string               `json:"target"`
+	ReadOnly    bool                 `json:"read_only,omitempty"`
+	Consistency string               `json:"consistency,omitempty"` // consistent, cached, delegated
+	Bind        *VolumeBindOptions   `json:"bind,omitempty"`
+	Volume      *VolumeVolumeOptions `json:"volume,omitempty"`
+	Tmpfs       *VolumeTmpfsOptions  `json:"tmpfs,omitempty"`
+	Image       *VolumeImageOptions  `json:"image,omitempty"`
+	Location    *SourceLocation      `json:"location,omitempty"` // Положение в исходном файле
+}
+
+// IsAnonymous проверяет, является ли монтирование анонимным томом
+func (v VolumeMount) IsAnonymous() bool {
+	return v.Type == "volume" && v.Source == ""
+}
+
+// IsNamed проверяет, является ли монтирование именованным томом
+func (v VolumeMount) IsNamed() bool {
+	return v.Type == "volume" && v.Source != ""
+}
+
+// IsBind проверяет, является ли монтирование каталогом или файлом хоста
+func (v VolumeMount) IsBind() bool {
+	return v.Type == "bind"
+}
+
+// VolumeBindOptions представляет параметры монтирования каталога хоста
+type VolumeBindOptions struct {
+	Propagation    string `json:"propagation,omitempty"` // shared, slave, private, rshared, rslave, rprivate
+	CreateHostPath bool   `json:"create_host_path,omitempty"`
+	SELinux        string `json:"selinux,omitempty"` // z, Z
+}
+
+// VolumeVolumeOptions представляет параметры монтирования именованного тома
+type VolumeVolumeOptions struct {
+	NoCopy  bool   `json:"nocopy,omitempty"`
+	Subpath string `json:"subpath,omitempty"`
+}
+
+// VolumeTmpfsOptions представляет параметры монтирования tmpfs
+type VolumeTmpfsOptions struct {
+	Size string `json:"size,omitempty"`
+	Mode uint32 `json:"mode,omitempty"`
+}
+
+// VolumeImageOptions представляет параметры монтирования образа
+type VolumeImageOptions struct {
+	Subpath string `json:"subpath,omitempty"`
+}
+
+// ServiceFileReference представляет подключение секрета или конфигурации к сервису.
+// Target пуст, если путь не задан: по умолчанию секрет монтируется в /run/secrets/<source>,
+// конфигурация - в /<source>
+type ServiceFileReference struct {
+	Source string  `json:"source"`
+	Target string  `json:"target,omitempty"`
+	UID    string  `json:"uid,omitempty"`
+	GID    string  `json:"gid,omitempty"`
+	Mode   *uint32 `json:"mode,omitempty"` // Права доступа, nil - по умолчанию (0444)
+}
+
+// EnvironmentVariable представляет переменную, объявленную в environment сервиса
+type EnvironmentVariable struct {
+	Name     string          `json:"name"`
+	Value    *string         `json:"value"`              // nil, если значение не задано (FOO, FOO: или FOO: null) и берется из окружения
+	Location *SourceLocation `json:"location,omitempty"` // Положение в исходном файле
+}
+
+// IsPassThrough проверяет, что значение переменной не задано и берется из окружения.
+// Пустое значение (FOO= или FOO: "") задано явно
+func (v EnvironmentVariable) IsPassThrough() bool {
+	return v.Value == nil
+}
+
+// EnvFileConfig представляет файл переменных окружения сервиса
+type EnvFileConfig struct {
+	Path     string `json:"path"`             // Путь к файлу, относительные пути разрешаются от директории проекта
+	Required bool   `json:"required"`         // Отсутствие обязательного файла является ошибкой
+	Format   string `json:"format,omitempty"` // Формат файла, см. EnvFileFormat*
+}
+
+// DeviceMapping представляет устройство хоста, доступное в контейнере.
+// Для CDI устройств (vendor.com/class=name) заполняется только Source
+type DeviceMapping struct {
+	Source      string `json:"source"`
+	Target      string `json:"target,omitempty"`
+	Permissions string `json:"permissions,omitempty"` // Комбинация r, w, m
+}
+
+// UlimitConfig представляет ограничение ресурса: единое значение или пара soft/hard
+type UlimitConfig struct {
+	Single int64 `json:"single,omitempty"`
+	Soft   int64 `json:"soft,omitempty"`
+	Hard   int64 `json:"hard,omitempty"`
 }
 
 // DeployConfig представляет конфигурацию развертывания
@@ -167,7 +366,33 @@ type HealthCheckConfig struct {
 // ExtendsConfig представляет конфигурацию расширения
 type ExtendsConfig struct {
 	File    string `json:"file,omitempty"`
-	Service string `json:"services,omitempty"`
+	Service string `json:"service,omitempty"`
+}
+
+// SourceLocation представляет положение элемента в исходном YAML файле.
+// Строки и колонки нумеруются с 1, File пуст для данных, переданных без файла
+type SourceLocation struct {
+	File      string `json:"file,omitempty"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"end_line,omitempty"`
+	EndColumn int    `json:"end_column,omitempty"`
+}
+
+// Уровни важности диагностик
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic представляет ошибку или предупреждение, найденные при разборе проекта
+type Diagnostic struct {
+	Severity string          `json:"severity"` // error, warning
+	Code     string          `json:"code,omitempty"`
+	Path     string          `json:"path,omitempty"` // Путь к элементу, например services.web.ports[2]
+	Message  string          `json:"message"`
+	Location *SourceLocation `json:"location,omitempty"`
+	Err      error           `json:"-"` // Исходная ошибка для errors.As
 }
 
 // ComposeProjectConfig представляет полную конфигурацию Docker Compose проекта
@@ -192,6 +417,16 @@ type ComposeProjectConfig struct {
 	// Конфигурации
 	Configs map[string]*ConfigConfig `json:"configs,omitempty"`
 
+	// Поля расширений x-* верхнего уровня
+	Extensions     map[string]interface{} `json:"extensions,omitempty"`
+	ExtensionNodes map[string]*yaml.Node  `json:"-"` // Исходные узлы YAML, заполняются с опцией WithExtensionNodes
+
+	// Положения элементов в исходных файлах по пути, например "services.web.ports[2]"
+	Locations map[string]SourceLocation `json:"-"`
+
+	// Ошибки и предупреждения разбора. Ошибки попадают сюда только с опцией WithErrorTolerance
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+
 	// Метаданные
 	Name      string    `json:"name"`
 	CreatedAt time.Time `json:"created_at"`
@@ -203,39 +438,69 @@ type ComposeProjectConfig struct {
 
 // NetworkConfig представляет конфигурацию сети
 type NetworkConfig struct {
-	Driver     string            `json:"driver,omitempty"`
-	DriverOpts map[string]string `json:"driver_opts,omitempty"`
-	External   bool              `json:"external,omitempty"`
-	Name       string            `json:"name,omitempty"`
-	Attachable bool              `json:"attachable,omitempty"`
-	Internal   bool              `json:"internal,omitempty"`
-	Labels     map[string]string `json:"labels,omitempty"`
+	Driver         string                 `json:"driver,omitempty"`
+	DriverOpts     map[string]string      `json:"driver_opts,omitempty"`
+	External       bool                   `json:"external,omitempty"`
+	Name           string                 `json:"name,omitempty"`
+	Attachable     bool                   `json:"attachable,omitempty"`
+	Internal       bool                   `json:"internal,omitempty"`
+	EnableIPv4     *bool                  `json:"enable_ipv4,omitempty"` // nil, если не задано (включен)
+	EnableIPv6     *bool                  `json:"enable_ipv6,omitempty"` // nil, если не задано
+	IPAM           *IPAMConfig            `json:"ipam,omitempty"`
+	Labels         map[string]string      `json:"labels,omitempty"`
+	Extensions     map[string]interface{} `json:"extensions,omitempty"` // Поля расширений x-*
+	ExtensionNodes map[string]*yaml.Node  `json:"-"`                    // Исходные узлы YAML полей расширений
+	Location       *SourceLocation        `json:"location,omitempty"`   // Положение в исходном файле
+}
+
+// IPAMConfig представляет настройки распределения адресов сети
+type IPAMConfig struct {
+	Driver  string            `json:"driver,omitempty"`
+	Config  []IPAMPool        `json:"config,omitempty"`
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// IPAMPool представляет пул адресов сети
+type IPAMPool struct {
+	Subnet       string            `json:"subnet,omitempty"`
+	IPRange      string            `json:"ip_range,omitempty"`
+	Gateway      string            `json:"gateway,omitempty"`
+	AuxAddresses map[string]string `json:"aux_addresses,omitempty"` // Имя хоста -> зарезервированный адрес
 }
 
 // VolumeConfig представляет конфигурацию тома
 type VolumeConfig struct {
-	Driver     string            `json:"driver,omitempty"`
-	DriverOpts map[string]string `json:"driver_opts,omitempty"`
-	External   bool              `json:"external,omitempty"`
-	Name       string            `json:"name,omitempty"`
-	Labels     map[string]string `json:"labels,omitempty"`
-	Order      int               `json:"order,omitempty"` // Порядковый номер тома в файле
+	Driver         string                 `json:"driver,omitempty"`
+	DriverOpts     map[string]string      `json:"driver_opts,omitempty"`
+	External       bool                   `json:"external,omitempty"`
+	Name           string                 `json:"name,omitempty"`
+	Labels         map[string]string      `json:"labels,omitempty"`
+	Order          int                    `json:"order,omitempty"`      // Порядковый номер тома в файле
+	Extensions     map[string]interface{} `json:"extensions,omitempty"` // Поля расширений x-*
+	ExtensionNodes map[string]*yaml.Node  `json:"-"`                    // Исходные узлы YAML полей расширений
+	Location       *SourceLocation        `json:"location,omitempty"`   // Положение в исходном файле
 }
 
 // SecretConfig представляет конфигурацию секрета
 type SecretConfig struct {
-	File     string            `json:"file,omitempty"`
-	External bool              `json:"external,omitempty"`
-	Name     string            `json:"name,omitempty"`
-	Labels   map[string]string `json:"labels,omitempty"`
+	File           string                 `json:"file,omitempty"`
+	External       bool                   `json:"external,omitempty"`
+	Name           string                 `json:"name,omitempty"`
+	Labels         map[string]string      `json:"labels,omitempty"`
+	Extensions     map[string]interface{} `json:"extensions,omitempty"` // Поля расширений x-*
+	ExtensionNodes map[string]*yaml.Node  `json:"-"`                    // Исходные узлы YAML полей расширений
+	Location       *SourceLocation        `json:"location,omitempty"`   // Положение в исходном файле
 }
 
 // ConfigConfig представляет конфигурацию конфигурации
 type ConfigConfig struct {
-	File     string            `json:"file,omitempty"`
-	External bool              `json:"external,omitempty"`
-	Name     string            `json:"name,omitempty"`
-	Labels   map[string]string `json:"labels,omitempty"`
+	File           string                 `json:"file,omitempty"`
+	External       bool                   `json:"external,omitempty"`
+	Name           string                 `json:"name,omitempty"`
+	Labels         map[string]string      `json:"labels,omitempty"`
+	Extensions     map[string]interface{} `json:"extensions,omitempty"` // Поля расширений x-*
+	ExtensionNodes map[string]*yaml.Node  `json:"-"`                    // Исходные узлы YAML полей расширений
+	Location       *SourceLocation        `json:"location,omitempty"`   // Положение в исходном файле
 }
 
 // ComposeServiceStatus представляет статус сервиса Docker Compose
@@ -283,6 +548,7 @@ type ReactFlowEdge struct {
 	Animated     bool                   `json:"animated,omitempty"`
 	Style        map[string]interface{} `json:"style,omitempty"`
 	Label        string                 `json:"label,omitempty"`
+	Data         map[string]interface{} `json:"data,omitempty"`
 	NetworkName  string                 `json:"networkName,omitempty"`
 	ServiceName  string                 `json:"serviceName,omitempty"`
 	LabelStyle   map[string]interface{} `json:"labelStyle,omitempty"`
@@ -307,6 +573,8 @@ type ReactFlowNodeData struct {
 	Status      string                 `json:"status,omitempty"`
 	Description string                 `json:"description,omitempty"`
 	Properties  map[string]interface{} `json:"properties,omitempty"`
+	Location    *SourceLocation        `json:"location,omitempty"` // Положение определения в исходном файле
+	Diagnostics []Diagnostic           `json:"diagnostics,omitempty"`
 }
 
 // ReactFlowGraph представляет полный граф для React Flow
@@ -343,6 +611,15 @@ type GraphLayoutOptions struct {
 	LastY              int    `json:"last_y"`
 }
 
+// YAMLOptions представляет опции сериализации проекта в YAML.
+// По умолчанию элементы, имеющие короткую форму (build, depends_on, ports, networks
+// сервиса, extra_hosts, env_file, volumes, devices, secrets и configs сервиса),
+// записываются в короткой форме, если она передает значение без потерь
+type YAMLOptions struct {
+	LongSyntax bool `json:"long_syntax"` // Всегда использовать длинную форму
+	Indent     int  `json:"indent"`      // Отступ, по умолчанию 2
+}
+
 type networkWithName struct {
 	name    string
 	network *NetworkConfig
@@ -9,18 +9,146 @@ import (
 	"io"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // ComposeParser представляет парсер Docker Compose файлов
-type ComposeParser struct{}
+type ComposeParser struct {
+	lookup             LookupFunc // Источник значений для интерполяции переменных
+	disableInterpolate bool       // Отключает подстановку переменных
+	extendsProvenance  bool       // Сохраняет происхождение полей, унаследованных через extends
+	extensionNodes     bool       // Сохраняет исходные узлы YAML полей расширений x-*
+	tolerant           bool       // Продолжает разбор после ошибок, собирая их в диагностики
+	legacySchemas      bool       // Выбирает схему проверки по ключу version
+	semantic           bool       // Проверяет связи между элементами проекта после разбора
+	strict             bool       // Сообщает о полях, не описанных в спецификации Compose
+}
+
+// ParserOption настраивает парсер Docker Compose файлов
+type ParserOption func(*ComposeParser)
+
+// WithLookup задает источник значений для интерполяции переменных
+// и переменных environment без значения
+func WithLookup(lookup LookupFunc) ParserOption {
+	return func(p *ComposeParser) {
+		p.lookup = lookup
+	}
+}
+
+// WithEnvironment задает значения переменных для интерполяции и переменных environment
+// без значения вместо окружения процесса
+func WithEnvironment(env map[string]string) ParserOption {
+	return WithLookup(MapLookup(env))
+}
+
+// WithoutInterpolation отключает подстановку переменных, значения сохраняются как есть
+func WithoutInterpolation() ParserOption {
+	return func(p *ComposeParser) {
+		p.disableInterpolate = true
+	}
+}
+
+// WithExtendsProvenance включает сохранение информации о том,
+// какие поля сервиса унаследованы от базового сервиса через extends
+func WithExtendsProvenance() ParserOption {
+	return func(p *ComposeParser) {
+		p.extendsProvenance = true
+	}
+}
+
+// WithExtensionNodes включает сохранение исходных узлов YAML полей расширений x-*
+// в поле ExtensionNodes конфигураций в дополнение к декодированным значениям
+func WithExtensionNodes() ParserOption {
+	return func(p *ComposeParser) {
+		p.extensionNodes = true
+	}
+}
+
+// WithErrorTolerance включает режим, в котором ошибки полей, сервисов и ресурсов
+// не прерывают разбор: проект строится из корректных частей, а ошибки возвращаются
+// в Diagnostics. Сервисы с ошибками получают статус "error". Синтаксические ошибки YAML
+// и ошибки чтения файлов по-прежнему возвращаются как ошибки
+func WithErrorTolerance() ParserOption {
+	return func(p *ComposeParser) {
+		p.tolerant = true
+	}
+}
+
+// WithLegacySchemas включает выбор JSON-схемы для ValidateFile и ValidateYAML по ключу
+// version: файлы формата 3.x проверяются схемой своей версии. Формат 2.x не поддерживается,
+// такие файлы проверяются по спецификации Compose с предупреждением
+func WithLegacySchemas() ParserOption {
+	return func(p *ComposeParser) {
+		p.legacySchemas = true
+	}
+}
+
+// WithSemanticValidation включает проверку связей между элементами проекта после разбора
+// (см. ValidateProject). Найденные ошибки возвращаются как ошибка разбора, а в режиме
+// WithErrorTolerance добавляются в Diagnostics вместе с предупреждениями
+func WithSemanticValidation() ParserOption {
+	return func(p *ComposeParser) {
+		p.semantic = true
+	}
+}
+
+// WithStrict включает строгий режим: поля, не описанные в спецификации Compose, на любом
+// уровне вложенности считаются ошибкой с подсказкой похожего известного поля. Поля
+// расширений x- допускаются
+func WithStrict() ParserOption {
+	return func(p *ComposeParser) {
+		p.strict = true
+	}
+}
 
 // NewComposeParser создает новый парсер Docker Compose файлов
-func NewComposeParser() *ComposeParser {
-	return &ComposeParser{}
+func NewComposeParser(opts ...ParserOption) *ComposeParser {
+	p := &ComposeParser{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// parseContext хранит состояние одного запуска парсинга
+type parseContext struct {
+	// inherited содержит поля сервисов, унаследованные через extends, по узлу сервиса
+	inherited map[*yaml.Node]map[string]inheritedField
+	// includeStack содержит цепочку подключаемых через include файлов
+	includeStack []string
+	// sources содержит файлы, из которых загружены узлы
+	sources map[*yaml.Node]string
+	// locations содержит положения элементов итогового документа по пути
+	locations map[string]SourceLocation
+	// diagnostics содержит ошибки и предупреждения, не прервавшие разбор
+	diagnostics []Diagnostic
+	// lookup - источник переменных для файлов проекта
+	lookup LookupFunc
+	// workingDir - директория проекта для относительных путей, "" для данных без файла
+	workingDir string
+	// rawEnds содержит конечные позиции интерполированных и блочных скаляров в исходном тексте
+	rawEnds map[scalarPosition]scalarPosition
+}
+
+// newParseContext создает состояние парсинга с источником переменных для файлов проекта
+func newParseContext(lookup LookupFunc) *parseContext {
+	return &parseContext{
+		lookup:    lookup,
+		inherited: make(map[*yaml.Node]map[string]inheritedField),
+		sources:   make(map[*yaml.Node]string),
+		locations: make(map[string]SourceLocation),
+		rawEnds:   make(map[scalarPosition]scalarPosition),
+	}
+}
+
+// lookupFunc возвращает источник переменных, по умолчанию окружение процесса
+func (p *ComposeParser) lookupFunc() LookupFunc {
+	if p.lookup != nil {
+		return p.lookup
+	}
+	return EnvLookup()
 }
 
 // ParseFile парсит Docker Compose файл и возвращает конфигурацию проекта
@@ -28,39 +156,27 @@ func (p *ComposeParser) ParseFile(filePath string) (*ComposeProjectConfig, error
 	return p.ParseFileWithName(filePath, "")
 }
 
+// ParseFileWithName парсит Docker Compose файл с указанным именем проекта.
+// Если имя не указано, оно определяется по переменной COMPOSE_PROJECT_NAME,
+// ключу name файла или директории файла
 func (p *ComposeParser) ParseFileWithName(filePath string, projectName string) (*ComposeProjectConfig, error) {
-	ext := strings.ToLower(filepath.Ext(filePath))
-	if ext != ".yaml" && ext != ".yml" {
-		return nil, fmt.Errorf("unsupported file extension: %s, expected .yaml or .yml", ext)
-	}
-
-	data, err := os.ReadFile(filePath)
+	ctx, projectName, err := p.fileProjectContext(projectName, []string{filePath})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file %s: %v", filePath, err)
+		return nil, err
 	}
-
-	//if projectName == "" {
-	//	baseName := filepath.Base(filePath)
-	//	projectName = strings.TrimSuffix(baseName, filepath.Ext(baseName))
-	//}
-
-	if projectName == "" {
-		absFile, err := filepath.Abs(filePath)
-		if err != nil {
-			return nil, err
-		}
-		projectDir := filepath.Dir(absFile)
-		projectName = filepath.Base(projectDir)
-		projectName = strings.ToLower(projectName)
+	rootNode, err := p.loadFile(ctx, filePath)
+	if err != nil {
+		return nil, err
 	}
 
-	return p.parseYAML(data, projectName)
+	return p.parseProject(ctx, rootNode, projectName)
 }
 
-// ParseYAML парсит YAML данные и возвращает конфигурацию проекта
-// Если имя проекта не указано, используется "docker-compose"
+// ParseYAML парсит YAML данные и возвращает конфигурацию проекта.
+// Если имя проекта не задано переменной COMPOSE_PROJECT_NAME или ключом name,
+// используется "docker-compose"
 func (p *ComposeParser) ParseYAML(data []byte) (*ComposeProjectConfig, error) {
-	return p.parseYAML(data, "docker-compose")
+	return p.parseYAML(data, "")
 }
 
 // ParseYAMLWithName парсит YAML данные с указанным именем проекта
@@ -70,10 +186,73 @@ func (p *ComposeParser) ParseYAMLWithName(data []byte, projectName string) (*Com
 
 // parseYAML парсит YAML данные и возвращает конфигурацию проекта
 func (p *ComposeParser) parseYAML(data []byte, projectName string) (*ComposeProjectConfig, error) {
+	var names []string
+	if projectName == "" {
+		names = []string{rawProjectName(data)}
+	}
+	ctx, projectName, err := p.projectContext(projectName, names, "")
+	if err != nil {
+		return nil, err
+	}
+	rootNode, err := p.loadDocument(ctx, data, ctx.lookup, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.resolveIncludes(ctx, rootNode, ""); err != nil {
+		return nil, ctx.completeError(err, "")
+	}
+	if err := p.resolveExtends(ctx, rootNode, ""); err != nil {
+		return nil, ctx.completeError(err, "")
+	}
+
+	return p.parseProject(ctx, rootNode, projectName)
+}
+
+// loadDocument разбирает YAML документ и выполняет интерполяцию переменных.
+// file - абсолютный путь к файлу документа или "" для данных без файла.
+// Возвращает корневой узел-отображение
+func (p *ComposeParser) loadDocument(ctx *parseContext, data []byte, lookup LookupFunc, file string) (*yaml.Node, error) {
 	// Парсим YAML с сохранением порядка ключей
 	var node yaml.Node
 	if err := yaml.Unmarshal(data, &node); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %v", err)
+		return nil, newSyntaxError(err, "failed to parse YAML")
+	}
+
+	// Извлекаем данные из корневого узла
+	if node.Kind != yaml.DocumentNode || len(node.Content) == 0 {
+		return nil, newSyntaxError(nil, "invalid YAML document")
+	}
+
+	rootNode := node.Content[0]
+	if rootNode.Kind != yaml.MappingNode {
+		return nil, newSyntaxError(nil, "root node is not a mapping")
+	}
+	ctx.recordBlockEnds(rootNode, data, file)
+
+	// Подставляем переменные до разбора сервисов
+	if !p.disableInterpolate {
+		if err := p.interpolateNode(ctx, rootNode, "", lookup, file); err != nil {
+			return nil, err
+		}
+	}
+
+	// Раскрываем алиасы и ключи слияния, чтобы узлы можно было изменять независимо
+	return expandNode(rootNode), nil
+}
+
+// parseProject строит конфигурацию проекта из корневого узла документа
+func (p *ComposeParser) parseProject(ctx *parseContext, rootNode *yaml.Node, projectName string) (*ComposeProjectConfig, error) {
+	// Применяем теги !reset и !override, оставшиеся после слияния файлов
+	p.applyMergeTags(rootNode)
+
+	// Запоминаем положения элементов итогового документа
+	ctx.collectLocations(nil, rootNode, "")
+
+	if p.strict {
+		if err := p.checkUnknownFields(ctx, rootNode); err != nil {
+			return nil, err
+		}
 	}
 
 	// Создаем конфигурацию проекта
@@ -90,16 +269,17 @@ func (p *ComposeParser) parseYAML(data []byte, projectName string) (*ComposeProj
 		CreatedAt:    now,
 		UpdatedAt:    now,
 		Status:       "parsed",
+		Locations:    ctx.locations,
 	}
 
-	// Извлекаем данные из корневого узла
-	if node.Kind != yaml.DocumentNode || len(node.Content) == 0 {
-		return nil, fmt.Errorf("invalid YAML document")
+	// Поля расширений верхнего уровня (x-*)
+	d := p.newNodeDecoder(rootNode, "")
+	if p.tolerant {
+		d.report = func(err error) { ctx.diagnose(SeverityError, err, "") }
 	}
-
-	rootNode := node.Content[0]
-	if rootNode.Kind != yaml.MappingNode {
-		return nil, fmt.Errorf("root node is not a mapping")
+	project.Extensions, project.ExtensionNodes = d.extensions()
+	if err := d.Err(); err != nil {
+		return nil, ctx.completeError(err, "")
 	}
 
 	// Обрабатываем все ключи в корневом узле
@@ -113,1110 +293,808 @@ func (p *ComposeParser) parseYAML(data []byte, projectName string) (*ComposeProj
 
 		key := keyNode.Value
 
+		var err error
 		switch key {
 		case "version":
-			if valueNode.Kind == yaml.ScalarNode {
-				project.Version = valueNode.Value
-			}
+			project.Version, err = decodeString(valueNode, key)
 
 		case "services":
-			if valueNode.Kind == yaml.MappingNode {
-				// Сохраняем порядок сервисов
-				for j := 0; j < len(valueNode.Content); j += 2 {
-					serviceKeyNode := valueNode.Content[j]
-					serviceValueNode := valueNode.Content[j+1]
-
-					if serviceKeyNode.Kind != yaml.ScalarNode {
-						continue
+			// Сохраняем порядок сервисов
+			err = forEachEntry(valueNode, key, func(serviceName string, serviceNode *yaml.Node, path string) error {
+				project.ServiceOrder = append(project.ServiceOrder, serviceName)
+
+				service, err := p.parseService(ctx, serviceName, serviceNode, path)
+				if err != nil {
+					if err := p.tolerate(ctx, err, ""); err != nil {
+						return err
 					}
-
-					serviceName := serviceKeyNode.Value
-
-					// Добавляем имя сервиса в порядок
-					project.ServiceOrder = append(project.ServiceOrder, serviceName)
-
-					// Парсим сервис
-					var serviceRaw interface{}
-					if err := serviceValueNode.Decode(&serviceRaw); err != nil {
-						return nil, fmt.Errorf("failed to decode services %s: %v", serviceName, err)
-					}
-
-					service, err := p.parseService(serviceName, serviceRaw)
-					if err != nil {
-						return nil, fmt.Errorf("failed to parse services %s: %v", serviceName, err)
-					}
-
-					// Устанавливаем порядковый номер
-					service.Order = len(project.ServiceOrder)
-
-					project.Services[serviceName] = service
+					// Сервис остается в проекте, чтобы его можно было показать с ошибкой
+					service = errorService(ctx, serviceName, path)
 				}
-			}
 
-		case "networks":
-			if valueNode.Kind == yaml.MappingNode {
-				for j := 0; j < len(valueNode.Content); j += 2 {
-					networkKeyNode := valueNode.Content[j]
-					networkValueNode := valueNode.Content[j+1]
-
-					if networkKeyNode.Kind != yaml.ScalarNode {
-						continue
-					}
+				// Устанавливаем порядковый номер
+				service.Order = len(project.ServiceOrder)
 
-					networkName := networkKeyNode.Value
+				if p.extendsProvenance {
+					service.InheritedFields = inheritedFields(ctx, serviceNode)
+				}
 
-					var networkRaw interface{}
-					if err := networkValueNode.Decode(&networkRaw); err != nil {
-						return nil, fmt.Errorf("failed to decode network %s: %v", networkName, err)
-					}
+				project.Services[serviceName] = service
+				return nil
+			})
 
-					network, err := p.parseNetwork(networkName, networkRaw)
-					if err != nil {
-						return nil, fmt.Errorf("failed to parse network %s: %v", networkName, err)
-					}
-					project.Networks[networkName] = network
+		case "networks":
+			err = forEachEntry(valueNode, key, func(networkName string, networkNode *yaml.Node, path string) error {
+				network, err := p.parseNetwork(ctx, networkName, networkNode, path)
+				if err != nil {
+					return p.tolerate(ctx, err, "")
 				}
-			}
+				project.Networks[networkName] = network
+				return nil
+			})
 
 		case "volumes":
-			if valueNode.Kind == yaml.MappingNode {
-				// Сохраняем порядок томов
-				for j := 0; j < len(valueNode.Content); j += 2 {
-					volumeKeyNode := valueNode.Content[j]
-					volumeValueNode := valueNode.Content[j+1]
-
-					if volumeKeyNode.Kind != yaml.ScalarNode {
-						continue
-					}
-
-					volumeName := volumeKeyNode.Value
-
-					// Добавляем имя тома в порядок
-					project.VolumeOrder = append(project.VolumeOrder, volumeName)
-
-					var volumeRaw interface{}
-					if err := volumeValueNode.Decode(&volumeRaw); err != nil {
-						return nil, fmt.Errorf("failed to decode volume %s: %v", volumeName, err)
-					}
-
-					volume, err := p.parseVolume(volumeName, volumeRaw)
-					if err != nil {
-						return nil, fmt.Errorf("failed to parse volume %s: %v", volumeName, err)
-					}
-
-					// Устанавливаем порядковый номер
-					volume.Order = len(project.VolumeOrder)
+			// Сохраняем порядок томов
+			err = forEachEntry(valueNode, key, func(volumeName string, volumeNode *yaml.Node, path string) error {
+				project.VolumeOrder = append(project.VolumeOrder, volumeName)
 
-					project.Volumes[volumeName] = volume
+				volume, err := p.parseVolume(ctx, volumeName, volumeNode, path)
+				if err != nil {
+					return p.tolerate(ctx, err, "")
 				}
-			}
-
-		case "secrets":
-			if valueNode.Kind == yaml.MappingNode {
-				for j := 0; j < len(valueNode.Content); j += 2 {
-					secretKeyNode := valueNode.Content[j]
-					secretValueNode := valueNode.Content[j+1]
 
-					if secretKeyNode.Kind != yaml.ScalarNode {
-						continue
-					}
-
-					secretName := secretKeyNode.Value
+				// Устанавливаем порядковый номер
+				volume.Order = len(project.VolumeOrder)
 
-					var secretRaw interface{}
-					if err := secretValueNode.Decode(&secretRaw); err != nil {
-						return nil, fmt.Errorf("failed to decode secret %s: %v", secretName, err)
-					}
+				project.Volumes[volumeName] = volume
+				return nil
+			})
 
-					secret, err := p.parseSecret(secretName, secretRaw)
-					if err != nil {
-						return nil, fmt.Errorf("failed to parse secret %s: %v", secretName, err)
-					}
-					project.Secrets[secretName] = secret
+		case "secrets":
+			err = forEachEntry(valueNode, key, func(secretName string, secretNode *yaml.Node, path string) error {
+				secret, err := p.parseSecret(ctx, secretName, secretNode, path)
+				if err != nil {
+					return p.tolerate(ctx, err, "")
 				}
-			}
+				project.Secrets[secretName] = secret
+				return nil
+			})
 
 		case "configs":
-			if valueNode.Kind == yaml.MappingNode {
-				for j := 0; j < len(valueNode.Content); j += 2 {
-					configKeyNode := valueNode.Content[j]
-					configValueNode := valueNode.Content[j+1]
-
-					if configKeyNode.Kind != yaml.ScalarNode {
-						continue
-					}
-
-					configName := configKeyNode.Value
-
-					var configRaw interface{}
-					if err := configValueNode.Decode(&configRaw); err != nil {
-						return nil, fmt.Errorf("failed to decode config %s: %v", configName, err)
-					}
-
-					config, err := p.parseConfig(configName, configRaw)
-					if err != nil {
-						return nil, fmt.Errorf("failed to parse config %s: %v", configName, err)
-					}
-					project.Configs[configName] = config
+			err = forEachEntry(valueNode, key, func(configName string, configNode *yaml.Node, path string) error {
+				config, err := p.parseConfig(ctx, configName, configNode, path)
+				if err != nil {
+					return p.tolerate(ctx, err, "")
 				}
-			}
+				project.Configs[configName] = config
+				return nil
+			})
+		}
+
+		if err = p.tolerate(ctx, err, ""); err != nil {
+			return nil, ctx.completeError(err, "")
 		}
 	}
 
+	project.Diagnostics = sortDiagnostics(ctx.diagnostics)
+	if p.semantic {
+		if err := p.semanticDiagnostics(project); err != nil {
+			return nil, err
+		}
+	}
+	attachServiceDiagnostics(project)
 	return project, nil
 }
 
 // parseService парсит конфигурацию сервиса
-func (p *ComposeParser) parseService(name string, raw interface{}) (*ComposeServiceConfig, error) {
+func (p *ComposeParser) parseService(ctx *parseContext, name string, node *yaml.Node, path string) (*ComposeServiceConfig, error) {
 	service := &ComposeServiceConfig{
 		Name:      name,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		Status:    "parsed",
+		Location:  ctx.locationAt(path),
 	}
 
-	serviceMap, ok := raw.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("services configuration must be a map")
+	d := p.newNodeDecoder(node, path)
+	if p.tolerant {
+		// Ошибочные поля пропускаются, остальные поля сервиса разбираются
+		d.report = func(err error) { ctx.diagnose(SeverityError, err, "") }
 	}
 
 	// Базовые поля
-	if image, ok := serviceMap["image"].(string); ok {
-		service.Image = image
-	}
-
-	if buildRaw, ok := serviceMap["build"]; ok {
-		build, err := p.parseBuild(buildRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse build config: %v", err)
-		}
-		service.Build = build
-	}
-
-	if commandRaw, ok := serviceMap["command"]; ok {
-		service.Command = p.parseStringOrSlice(commandRaw)
-	}
-
-	if entrypointRaw, ok := serviceMap["entrypoint"]; ok {
-		service.Entrypoint = p.parseStringOrSlice(entrypointRaw)
-	}
-
-	if workingDir, ok := serviceMap["working_dir"].(string); ok {
-		service.WorkingDir = workingDir
-	}
+	service.Image = d.stringField("image")
 
-	if user, ok := serviceMap["user"].(string); ok {
-		service.User = user
-	}
+	d.field("build", func(node *yaml.Node, path string) (err error) {
+		service.Build, err = p.parseBuild(node, path)
+		return err
+	})
 
-	if platform, ok := serviceMap["platform"].(string); ok {
-		service.Platform = platform
-	}
+	service.ContainerName = d.stringField("container_name")
+	service.Command = d.stringListField("command")
+	service.Entrypoint = d.stringListField("entrypoint")
+	service.WorkingDir = d.stringField("working_dir")
+	service.User = d.stringField("user")
+	service.Platform = d.stringField("platform")
+	service.Profiles = d.stringListField("profiles")
 
 	// Зависимости и перезапуск
-	if dependsOnRaw, ok := serviceMap["depends_on"]; ok {
-		service.DependsOn = p.parseStringOrSlice(dependsOnRaw)
-	}
+	d.field("depends_on", func(node *yaml.Node, path string) (err error) {
+		service.DependsOn, err = p.parseDependsOn(node, path)
+		return err
+	})
 
-	if restart, ok := serviceMap["restart"].(string); ok {
-		service.Restart = restart
-	}
+	service.Restart = d.stringField("restart")
 
 	// Сеть и порты
-	if portsRaw, ok := serviceMap["ports"]; ok {
-		ports, err := p.parsePorts(portsRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse ports: %v", err)
-		}
-		service.Ports = ports
-	}
+	d.field("ports", func(node *yaml.Node, path string) (err error) {
+		service.Ports, err = p.parsePorts(ctx, node, path)
+		return err
+	})
 
-	if exposeRaw, ok := serviceMap["expose"]; ok {
-		service.Expose = p.parseStringOrSlice(exposeRaw)
-	}
+	service.Expose = d.stringListField("expose")
 
-	if networksRaw, ok := serviceMap["networks"]; ok {
-		service.Networks = p.parseStringOrSlice(networksRaw)
-	}
+	d.field("networks", func(node *yaml.Node, path string) (err error) {
+		service.Networks, err = p.parseServiceNetworks(ctx, node, path)
+		return err
+	})
 
-	if networkMode, ok := serviceMap["network_mode"].(string); ok {
-		service.NetworkMode = networkMode
-	}
+	service.NetworkMode = d.stringField("network_mode")
 
 	// Переменные окружения
-	if envRaw, ok := serviceMap["environment"]; ok {
-		env, err := p.parseEnvironment(envRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse environment: %v", err)
-		}
-		service.Environment = env
-	}
-
-	if envFileRaw, ok := serviceMap["env_file"]; ok {
-		service.EnvFile = p.parseStringOrSlice(envFileRaw)
-	}
+	d.field("environment", func(node *yaml.Node, path string) (err error) {
+		service.EnvironmentVariables, err = p.parseEnvironment(ctx, node, path)
+		return err
+	})
+
+	var envFileValues map[string]string
+	d.field("env_file", func(node *yaml.Node, path string) (err error) {
+		service.EnvFile, envFileValues, err = p.parseEnvFiles(ctx, node, path)
+		return err
+	})
+	service.Environment = mergeEnvironment(envFileValues, p.resolveEnvironment(ctx, service.EnvironmentVariables))
 
 	// Тома
-	if volumesRaw, ok := serviceMap["volumes"]; ok {
-		volumes, err := p.parseVolumeMounts(volumesRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse volumes: %v", err)
-		}
-		service.Volumes = volumes
-	}
-
-	if volumesFromRaw, ok := serviceMap["volumes_from"]; ok {
-		service.VolumesFrom = p.parseStringOrSlice(volumesFromRaw)
-	}
+	d.field("volumes", func(node *yaml.Node, path string) (err error) {
+		service.Volumes, err = p.parseVolumeMounts(ctx, node, path)
+		return err
+	})
+
+	service.VolumesFrom = d.stringListField("volumes_from")
+
+	// Секреты и конфигурации
+	d.field("secrets", func(node *yaml.Node, path string) (err error) {
+		service.Secrets, err = p.parseServiceFileReferences(node, path)
+		return err
+	})
+	d.field("configs", func(node *yaml.Node, path string) (err error) {
+		service.Configs, err = p.parseServiceFileReferences(node, path)
+		return err
+	})
 
 	// Ресурсы
-	if deployRaw, ok := serviceMap["deploy"]; ok {
-		deploy, err := p.parseDeploy(deployRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse deploy config: %v", err)
-		}
-		service.Deploy = deploy
-	}
-
-	if cpuShares, ok := serviceMap["cpu_shares"].(int64); ok {
-		service.CPUShares = cpuShares
-	}
-
-	if cpuset, ok := serviceMap["cpuset"].(string); ok {
-		service.CPUSet = cpuset
-	}
+	d.field("deploy", func(node *yaml.Node, path string) (err error) {
+		service.Deploy, err = p.parseDeploy(node, path)
+		return err
+	})
 
-	if cpuQuota, ok := serviceMap["cpu_quota"].(int64); ok {
-		service.CPUQuota = cpuQuota
+	service.CPUShares = d.intField("cpu_shares")
+	service.CPUSet = d.stringField("cpuset")
+	service.CPUQuota = d.intField("cpu_quota")
+	service.CPUs = d.floatField("cpus")
+	service.Memory = d.stringField("memory")
+	service.MemorySwap = d.stringField("memory_swap")
+	if d.has("memswap_limit") {
+		service.MemorySwap = d.stringField("memswap_limit")
 	}
 
-	if cpus, ok := serviceMap["cpus"].(float64); ok {
-		service.CPUs = cpus
-	}
-
-	if memory, ok := serviceMap["memory"].(string); ok {
-		service.Memory = memory
-	}
-
-	if memorySwap, ok := serviceMap["memory_swap"].(string); ok {
-		service.MemorySwap = memorySwap
-	}
+	// Параметры контейнера
+	p.parseContainerOptions(d, service)
 
 	// Логирование
-	if loggingRaw, ok := serviceMap["logging"]; ok {
-		logging, err := p.parseLogging(loggingRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse logging config: %v", err)
-		}
-		service.Logging = logging
-	}
+	d.field("logging", func(node *yaml.Node, path string) (err error) {
+		service.Logging, err = p.parseLogging(node, path)
+		return err
+	})
 
 	// Здоровье
-	if healthcheckRaw, ok := serviceMap["healthcheck"]; ok {
-		healthcheck, err := p.parseHealthcheck(healthcheckRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse healthcheck config: %v", err)
-		}
-		service.HealthCheck = healthcheck
-	}
+	d.field("healthcheck", func(node *yaml.Node, path string) (err error) {
+		service.HealthCheck, err = p.parseHealthcheck(node, path)
+		return err
+	})
 
 	// Метки
-	if labelsRaw, ok := serviceMap["labels"]; ok {
-		labels, err := p.parseLabels(labelsRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse labels: %v", err)
-		}
-		service.Labels = labels
-	}
+	service.Labels = d.keyValueField("labels")
 
 	// Расширения
-	if extendsRaw, ok := serviceMap["extends"]; ok {
-		extends, err := p.parseExtends(extendsRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse extends config: %v", err)
-		}
-		service.Extends = extends
+	service.Extensions, service.ExtensionNodes = d.extensions()
+
+	d.field("extends", func(node *yaml.Node, path string) (err error) {
+		service.Extends, err = p.parseExtends(node, path)
+		return err
+	})
+
+	if err := d.Err(); err != nil {
+		return nil, err
 	}
 
 	return service, nil
 }
 
 // parseBuild парсит конфигурацию сборки
-func (p *ComposeParser) parseBuild(raw interface{}) (*BuildConfig, error) {
+func (p *ComposeParser) parseBuild(node *yaml.Node, path string) (*BuildConfig, error) {
 	build := &BuildConfig{}
 
-	switch v := raw.(type) {
-	case string:
-		build.Context = v
-	case map[string]interface{}:
-		if context, ok := v["context"].(string); ok {
-			build.Context = context
-		}
-		if dockerfile, ok := v["dockerfile"].(string); ok {
-			build.Dockerfile = dockerfile
-		}
-		if argsRaw, ok := v["args"]; ok {
-			args, err := p.parseLabels(argsRaw)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse build args: %v", err)
-			}
-			build.Args = args
-		}
-		if target, ok := v["target"].(string); ok {
-			build.Target = target
-		}
-		if cacheFromRaw, ok := v["cache_from"]; ok {
-			build.CacheFrom = p.parseStringOrSlice(cacheFromRaw)
-		}
-		if labelsRaw, ok := v["labels"]; ok {
-			labels, err := p.parseLabels(labelsRaw)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse build labels: %v", err)
-			}
-			build.Labels = labels
-		}
-	default:
-		return nil, fmt.Errorf("invalid build configuration type: %T", raw)
+	// Короткая форма содержит только контекст сборки
+	if node.Kind == yaml.ScalarNode {
+		build.Context = node.Value
+		return build, nil
+	}
+
+	d := p.newNodeDecoder(node, path)
+	build.Context = d.stringField("context")
+	build.Dockerfile = d.stringField("dockerfile")
+	build.DockerfileInline = d.stringField("dockerfile_inline")
+	build.Args = d.keyValueField("args")
+	build.Target = d.stringField("target")
+	build.CacheFrom = d.stringListField("cache_from")
+	build.CacheTo = d.stringListField("cache_to")
+	build.NoCache = d.boolField("no_cache")
+	build.Pull = d.boolField("pull")
+	build.Labels = d.keyValueField("labels")
+	build.AdditionalContexts = d.keyValueField("additional_contexts")
+	build.SSH = d.keyValueField("ssh")
+	d.field("secrets", func(node *yaml.Node, path string) (err error) {
+		build.Secrets, err = p.parseServiceFileReferences(node, path)
+		return err
+	})
+	build.Platforms = d.stringListField("platforms")
+	build.Tags = d.stringListField("tags")
+	build.Network = d.stringField("network")
+	build.ShmSize = d.stringField("shm_size")
+	d.field("extra_hosts", func(node *yaml.Node, path string) (err error) {
+		build.ExtraHosts, err = parseExtraHosts(node, path)
+		return err
+	})
+	build.Isolation = d.stringField("isolation")
+	build.Privileged = d.boolField("privileged")
+	d.field("ulimits", func(node *yaml.Node, path string) (err error) {
+		build.Ulimits, err = p.parseUlimits(node, path)
+		return err
+	})
+	build.Entitlements = d.stringListField("entitlements")
+
+	if build.Dockerfile != "" && build.DockerfileInline != "" {
+		d.fail(newValidationError(ErrCodeConflictingResource, d.value("dockerfile_inline"), d.fieldPath("dockerfile_inline"),
+			"dockerfile and dockerfile_inline cannot be combined"))
+	}
+
+	if err := d.Err(); err != nil {
+		return nil, err
 	}
 
 	return build, nil
 }
 
-// parsePorts парсит маппинг портов
-func (p *ComposeParser) parsePorts(raw interface{}) ([]PortMapping, error) {
-	var ports []PortMapping
+// parseDependsOn парсит зависимости сервиса в короткой (список имен)
+// или полной (отображение с condition, restart и required) форме
+func (p *ComposeParser) parseDependsOn(node *yaml.Node, path string) ([]ServiceDependency, error) {
+	var dependencies []ServiceDependency
 
-	switch v := raw.(type) {
-	case []interface{}:
-		for _, portRaw := range v {
-			port, err := p.parsePort(portRaw)
-			if err != nil {
-				return nil, err
-			}
-			ports = append(ports, *port)
+	if node.Kind != yaml.MappingNode {
+		names, err := decodeStringList(node, path)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			dependencies = append(dependencies, ServiceDependency{
+				Service:   name,
+				Condition: DependencyConditionStarted,
+				Required:  true,
+			})
 		}
-	default:
-		return nil, fmt.Errorf("invalid ports configuration type: %T", raw)
+		return dependencies, nil
 	}
 
-	return ports, nil
-}
-
-// parsePort парсит один порт
-func (p *ComposeParser) parsePort(raw interface{}) (*PortMapping, error) {
-	port := &PortMapping{}
+	err := forEachEntry(node, path, func(name string, optionsNode *yaml.Node, path string) error {
+		d := p.newNodeDecoder(optionsNode, path)
 
-	switch v := raw.(type) {
-	case string:
-		// Парсим строку формата "8080:80" или "8080:80/tcp"
-		parts := strings.Split(v, ":")
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid port format: %s", v)
+		dependency := ServiceDependency{
+			Service:   name,
+			Condition: DependencyConditionStarted,
+			Required:  true,
 		}
-
-		// Парсим опубликованный порт
-		publishedParts := strings.Split(parts[0], "/")
-		if len(publishedParts) > 0 {
-			if portNum, err := p.parsePortNumber(publishedParts[0]); err == nil {
-				port.Published = uint16(portNum)
-			}
+		if d.has("condition") {
+			dependency.Condition = d.stringField("condition")
 		}
-
-		// Парсим целевой порт и протокол
-		targetParts := strings.Split(parts[1], "/")
-		if len(targetParts) > 0 {
-			if portNum, err := p.parsePortNumber(targetParts[0]); err == nil {
-				port.Target = uint16(portNum)
-			}
-		}
-		if len(targetParts) > 1 {
-			port.Protocol = targetParts[1]
+		dependency.Restart = d.boolField("restart")
+		if d.has("required") {
+			dependency.Required = d.boolField("required")
 		}
 
-	case map[string]interface{}:
-		if target, ok := v["target"].(int); ok {
-			port.Target = uint16(target)
-		}
-		if published, ok := v["published"].(int); ok {
-			port.Published = uint16(published)
-		}
-		if protocol, ok := v["protocol"].(string); ok {
-			port.Protocol = protocol
-		}
-		if mode, ok := v["mode"].(string); ok {
-			port.Mode = mode
+		if err := d.Err(); err != nil {
+			return err
 		}
-	default:
-		return nil, fmt.Errorf("invalid port configuration type: %T", raw)
-	}
 
-	return port, nil
-}
+		switch dependency.Condition {
+		case DependencyConditionStarted, DependencyConditionHealthy, DependencyConditionCompleted:
+		default:
+			return invalidValueError(d.value("condition"), d.fieldPath("condition"), "invalid depends_on condition %q", dependency.Condition)
+		}
 
-// parsePortNumber парсит номер порта из строки
-func (p *ComposeParser) parsePortNumber(s string) (int, error) {
-	var port int
-	_, err := fmt.Sscanf(s, "%d", &port)
+		dependencies = append(dependencies, dependency)
+		return nil
+	})
 	if err != nil {
-		return 0, fmt.Errorf("invalid port number: %s", s)
+		return nil, err
 	}
-	return port, nil
+
+	return dependencies, nil
 }
 
-// parseEnvironment парсит переменные окружения
-func (p *ComposeParser) parseEnvironment(raw interface{}) (map[string]string, error) {
-	env := make(map[string]string)
-
-	switch v := raw.(type) {
-	case []interface{}:
-		for _, envRaw := range v {
-			if envStr, ok := envRaw.(string); ok {
-				parts := strings.SplitN(envStr, "=", 2)
-				if len(parts) == 2 {
-					env[parts[0]] = parts[1]
-				} else {
-					env[parts[0]] = ""
-				}
-			}
+// parseServiceNetworks парсит подключения сервиса к сетям в короткой (список имен)
+// или полной (отображение с настройками подключения) форме
+func (p *ComposeParser) parseServiceNetworks(ctx *parseContext, node *yaml.Node, path string) ([]ServiceNetworkConfig, error) {
+	var networks []ServiceNetworkConfig
+
+	if node.Kind == yaml.SequenceNode {
+		names, err := decodeStringList(node, path)
+		if err != nil {
+			return nil, err
 		}
-	case map[string]interface{}:
-		for key, value := range v {
-			if strValue, ok := value.(string); ok {
-				env[key] = strValue
-			} else {
-				env[key] = fmt.Sprintf("%v", value)
-			}
+		for i, name := range names {
+			networks = append(networks, ServiceNetworkConfig{
+				Name:     name,
+				Location: ctx.locationAt(indexPath(path, i)),
+			})
 		}
-	default:
-		return nil, fmt.Errorf("invalid environment configuration type: %T", raw)
+		return networks, nil
 	}
 
-	return env, nil
-}
-
-// parseVolumeMounts парсит монтирования томов
-func (p *ComposeParser) parseVolumeMounts(raw interface{}) ([]VolumeMount, error) {
-	var volumes []VolumeMount
-
-	switch v := raw.(type) {
-	case []interface{}:
-		for _, volumeRaw := range v {
-			volume, err := p.parseVolumeMount(volumeRaw)
-			if err != nil {
-				return nil, err
-			}
-			volumes = append(volumes, *volume)
+	err := forEachEntry(node, path, func(name string, networkNode *yaml.Node, path string) error {
+		network, err := p.parseServiceNetwork(ctx, name, networkNode, path)
+		if err != nil {
+			return err
 		}
-	default:
-		return nil, fmt.Errorf("invalid volumes configuration type: %T", raw)
+		networks = append(networks, *network)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return volumes, nil
+	return networks, nil
 }
 
-// parseVolumeMount парсит одно монтирование тома
-func (p *ComposeParser) parseVolumeMount(raw interface{}) (*VolumeMount, error) {
-	volume := &VolumeMount{
-		Type: "volume", // значение по умолчанию
-	}
-
-	switch v := raw.(type) {
-	case string:
-		// Парсим строку формата "/host/path:/container/path:ro"
-		parts := strings.Split(v, ":")
-		if len(parts) < 2 || len(parts) > 3 {
-			return nil, fmt.Errorf("invalid volume format: %s", v)
-		}
-
-		// Определяем тип
-		if strings.HasPrefix(parts[0], "/") || strings.HasPrefix(parts[0], ".") {
-			volume.Type = "bind"
-			volume.Source = parts[0]
-		} else {
-			volume.Type = "volume"
-			volume.Source = parts[0]
-		}
-
-		volume.Target = parts[1]
+// parseServiceNetwork парсит настройки подключения сервиса к одной сети.
+// Значение null означает подключение без дополнительных настроек
+func (p *ComposeParser) parseServiceNetwork(ctx *parseContext, name string, node *yaml.Node, path string) (*ServiceNetworkConfig, error) {
+	network := &ServiceNetworkConfig{Name: name, Location: ctx.locationAt(path)}
 
-		if len(parts) == 3 {
-			options := parts[2]
-			if options == "ro" {
-				volume.ReadOnly = true
-			} else {
-				volume.Consistency = options
-			}
-		}
+	d := p.newNodeDecoder(node, path)
+	network.Aliases = d.stringListField("aliases")
+	network.IPv4Address = d.stringField("ipv4_address")
+	network.IPv6Address = d.stringField("ipv6_address")
+	network.LinkLocalIPs = d.stringListField("link_local_ips")
+	network.MacAddress = d.stringField("mac_address")
+	network.DriverOpts = d.keyValueField("driver_opts")
+	network.Priority = int(d.intField("priority"))
+	network.GwPriority = int(d.intField("gw_priority"))
+	network.InterfaceName = d.stringField("interface_name")
 
-	case map[string]interface{}:
-		if typ, ok := v["type"].(string); ok {
-			volume.Type = typ
-		}
-		if source, ok := v["source"].(string); ok {
-			volume.Source = source
-		}
-		if target, ok := v["target"].(string); ok {
-			volume.Target = target
-		}
-		if readOnly, ok := v["read_only"].(bool); ok {
-			volume.ReadOnly = readOnly
-		}
-		if consistency, ok := v["consistency"].(string); ok {
-			volume.Consistency = consistency
-		}
-	default:
-		return nil, fmt.Errorf("invalid volume configuration type: %T", raw)
+	if err := d.Err(); err != nil {
+		return nil, err
 	}
 
-	return volume, nil
+	return network, nil
 }
 
 // parseDeploy парсит конфигурацию развертывания
-func (p *ComposeParser) parseDeploy(raw interface{}) (*DeployConfig, error) {
+func (p *ComposeParser) parseDeploy(node *yaml.Node, path string) (*DeployConfig, error) {
 	deploy := &DeployConfig{}
 
-	deployMap, ok := raw.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("deploy configuration must be a map")
-	}
-
-	if mode, ok := deployMap["mode"].(string); ok {
-		deploy.Mode = mode
-	}
+	d := p.newNodeDecoder(node, path)
+	deploy.Mode = d.stringField("mode")
+	deploy.Replicas = d.uintField("replicas")
 
-	if replicas, ok := deployMap["replicas"].(int); ok {
-		deploy.Replicas = uint64(replicas)
-	}
+	d.field("placement", func(node *yaml.Node, path string) (err error) {
+		deploy.Placement, err = p.parsePlacement(node, path)
+		return err
+	})
 
-	if placementRaw, ok := deployMap["placement"]; ok {
-		placement, err := p.parsePlacement(placementRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse placement: %v", err)
-		}
-		deploy.Placement = placement
-	}
+	d.field("resources", func(node *yaml.Node, path string) (err error) {
+		deploy.Resources, err = p.parseResources(node, path)
+		return err
+	})
 
-	if resourcesRaw, ok := deployMap["resources"]; ok {
-		resources, err := p.parseResources(resourcesRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse resources: %v", err)
-		}
-		deploy.Resources = resources
-	}
+	d.field("restart_policy", func(node *yaml.Node, path string) (err error) {
+		deploy.RestartPolicy, err = p.parseRestartPolicy(node, path)
+		return err
+	})
 
-	if restartPolicyRaw, ok := deployMap["restart_policy"]; ok {
-		restartPolicy, err := p.parseRestartPolicy(restartPolicyRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse restart policy: %v", err)
-		}
-		deploy.RestartPolicy = restartPolicy
-	}
+	d.field("update_config", func(node *yaml.Node, path string) (err error) {
+		deploy.UpdateConfig, err = p.parseUpdateConfig(node, path)
+		return err
+	})
 
-	if updateConfigRaw, ok := deployMap["update_config"]; ok {
-		updateConfig, err := p.parseUpdateConfig(updateConfigRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse update config: %v", err)
-		}
-		deploy.UpdateConfig = updateConfig
-	}
+	d.field("rollback_config", func(node *yaml.Node, path string) (err error) {
+		deploy.RollbackConfig, err = p.parseRollbackConfig(node, path)
+		return err
+	})
 
-	if rollbackConfigRaw, ok := deployMap["rollback_config"]; ok {
-		rollbackConfig, err := p.parseRollbackConfig(rollbackConfigRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse rollback config: %v", err)
-		}
-		deploy.RollbackConfig = rollbackConfig
+	if err := d.Err(); err != nil {
+		return nil, err
 	}
 
 	return deploy, nil
 }
 
 // parsePlacement парсит конфигурацию размещения
-func (p *ComposeParser) parsePlacement(raw interface{}) (*PlacementConfig, error) {
+func (p *ComposeParser) parsePlacement(node *yaml.Node, path string) (*PlacementConfig, error) {
 	placement := &PlacementConfig{}
 
-	placementMap, ok := raw.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("placement configuration must be a map")
-	}
+	d := p.newNodeDecoder(node, path)
+	placement.Constraints = d.stringListField("constraints")
 
-	if constraintsRaw, ok := placementMap["constraints"]; ok {
-		placement.Constraints = p.parseStringOrSlice(constraintsRaw)
-	}
+	// Предпочтения задаются списком отображений вида {spread: node.labels.zone}
+	d.field("preferences", func(node *yaml.Node, path string) error {
+		return forEachItem(node, path, func(item *yaml.Node, path string) error {
+			if item.Kind == yaml.ScalarNode {
+				placement.Preferences = append(placement.Preferences, item.Value)
+				return nil
+			}
+			return forEachEntry(item, path, func(key string, value *yaml.Node, path string) error {
+				preference, err := decodeString(value, path)
+				if err != nil {
+					return err
+				}
+				placement.Preferences = append(placement.Preferences, key+"="+preference)
+				return nil
+			})
+		})
+	})
 
-	if preferencesRaw, ok := placementMap["preferences"]; ok {
-		placement.Preferences = p.parseStringOrSlice(preferencesRaw)
-	}
+	placement.MaxReplicas = d.uintField("max_replicas")
 
-	if maxReplicas, ok := placementMap["max_replicas"].(int); ok {
-		placement.MaxReplicas = uint64(maxReplicas)
+	if err := d.Err(); err != nil {
+		return nil, err
 	}
 
 	return placement, nil
 }
 
 // parseResources парсит требования к ресурсам
-func (p *ComposeParser) parseResources(raw interface{}) (*ResourceRequirements, error) {
+func (p *ComposeParser) parseResources(node *yaml.Node, path string) (*ResourceRequirements, error) {
 	resources := &ResourceRequirements{}
 
-	resourcesMap, ok := raw.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("resources configuration must be a map")
-	}
-
-	if limitsRaw, ok := resourcesMap["limits"]; ok {
-		limits, err := p.parseResourceLimits(limitsRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse limits: %v", err)
-		}
-		resources.Limits = limits
-	}
+	d := p.newNodeDecoder(node, path)
+	d.field("limits", func(node *yaml.Node, path string) (err error) {
+		resources.Limits, err = p.parseResourceLimits(node, path)
+		return err
+	})
+	d.field("reservations", func(node *yaml.Node, path string) (err error) {
+		resources.Reservations, err = p.parseResourceLimits(node, path)
+		return err
+	})
 
-	if reservationsRaw, ok := resourcesMap["reservations"]; ok {
-		reservations, err := p.parseResourceLimits(reservationsRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse reservations: %v", err)
-		}
-		resources.Reservations = reservations
+	if err := d.Err(); err != nil {
+		return nil, err
 	}
 
 	return resources, nil
 }
 
 // parseResourceLimits парсит лимиты ресурсов
-func (p *ComposeParser) parseResourceLimits(raw interface{}) (*ResourceLimits, error) {
+func (p *ComposeParser) parseResourceLimits(node *yaml.Node, path string) (*ResourceLimits, error) {
 	limits := &ResourceLimits{}
 
-	limitsMap, ok := raw.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("resource limits configuration must be a map")
-	}
-
-	if cpus, ok := limitsMap["cpus"].(string); ok {
-		limits.CPUs = cpus
-	}
-
-	if memory, ok := limitsMap["memory"].(string); ok {
-		limits.Memory = memory
-	}
+	d := p.newNodeDecoder(node, path)
+	limits.CPUs = d.numberField("cpus")
+	limits.Memory = d.stringField("memory")
+	limits.Pids = d.intField("pids")
 
-	if pids, ok := limitsMap["pids"].(int64); ok {
-		limits.Pids = pids
+	if err := d.Err(); err != nil {
+		return nil, err
 	}
 
 	return limits, nil
 }
 
 // parseRestartPolicy парсит политику перезапуска
-func (p *ComposeParser) parseRestartPolicy(raw interface{}) (*RestartPolicyConfig, error) {
+func (p *ComposeParser) parseRestartPolicy(node *yaml.Node, path string) (*RestartPolicyConfig, error) {
 	policy := &RestartPolicyConfig{}
 
-	policyMap, ok := raw.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("restart policy configuration must be a map")
-	}
-
-	if condition, ok := policyMap["condition"].(string); ok {
-		policy.Condition = condition
-	}
-
-	if delay, ok := policyMap["delay"].(string); ok {
-		policy.Delay = delay
-	}
+	d := p.newNodeDecoder(node, path)
+	policy.Condition = d.stringField("condition")
+	policy.Delay = d.stringField("delay")
+	policy.MaxAttempts = d.uintField("max_attempts")
+	policy.Window = d.stringField("window")
 
-	if maxAttempts, ok := policyMap["max_attempts"].(int); ok {
-		policy.MaxAttempts = uint64(maxAttempts)
-	}
-
-	if window, ok := policyMap["window"].(string); ok {
-		policy.Window = window
+	if err := d.Err(); err != nil {
+		return nil, err
 	}
 
 	return policy, nil
 }
 
 // parseUpdateConfig парсит конфигурацию обновления
-func (p *ComposeParser) parseUpdateConfig(raw interface{}) (*UpdateConfig, error) {
+func (p *ComposeParser) parseUpdateConfig(node *yaml.Node, path string) (*UpdateConfig, error) {
 	config := &UpdateConfig{}
 
-	configMap, ok := raw.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("update configuration must be a map")
-	}
-
-	if parallelism, ok := configMap["parallelism"].(int); ok {
-		config.Parallelism = uint64(parallelism)
-	}
-
-	if delay, ok := configMap["delay"].(string); ok {
-		config.Delay = delay
-	}
+	d := p.newNodeDecoder(node, path)
+	config.Parallelism = d.uintField("parallelism")
+	config.Delay = d.stringField("delay")
+	config.FailureAction = d.stringField("failure_action")
+	config.Monitor = d.stringField("monitor")
+	config.MaxFailureRatio = d.numberField("max_failure_ratio")
+	config.Order = d.stringField("order")
 
-	if failureAction, ok := configMap["failure_action"].(string); ok {
-		config.FailureAction = failureAction
-	}
-
-	if monitor, ok := configMap["monitor"].(string); ok {
-		config.Monitor = monitor
-	}
-
-	if maxFailureRatio, ok := configMap["max_failure_ratio"].(string); ok {
-		config.MaxFailureRatio = maxFailureRatio
-	}
-
-	if order, ok := configMap["order"].(string); ok {
-		config.Order = order
+	if err := d.Err(); err != nil {
+		return nil, err
 	}
 
 	return config, nil
 }
 
 // parseRollbackConfig парсит конфигурацию отката
-func (p *ComposeParser) parseRollbackConfig(raw interface{}) (*RollbackConfig, error) {
+func (p *ComposeParser) parseRollbackConfig(node *yaml.Node, path string) (*RollbackConfig, error) {
 	config := &RollbackConfig{}
 
-	configMap, ok := raw.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("rollback configuration must be a map")
-	}
-
-	if parallelism, ok := configMap["parallelism"].(int); ok {
-		config.Parallelism = uint64(parallelism)
-	}
+	d := p.newNodeDecoder(node, path)
+	config.Parallelism = d.uintField("parallelism")
+	config.Delay = d.stringField("delay")
+	config.FailureAction = d.stringField("failure_action")
+	config.Monitor = d.stringField("monitor")
+	config.MaxFailureRatio = d.numberField("max_failure_ratio")
+	config.Order = d.stringField("order")
 
-	if delay, ok := configMap["delay"].(string); ok {
-		config.Delay = delay
-	}
-
-	if failureAction, ok := configMap["failure_action"].(string); ok {
-		config.FailureAction = failureAction
-	}
-
-	if monitor, ok := configMap["monitor"].(string); ok {
-		config.Monitor = monitor
-	}
-
-	if maxFailureRatio, ok := configMap["max_failure_ratio"].(string); ok {
-		config.MaxFailureRatio = maxFailureRatio
-	}
-
-	if order, ok := configMap["order"].(string); ok {
-		config.Order = order
+	if err := d.Err(); err != nil {
+		return nil, err
 	}
 
 	return config, nil
 }
 
 // parseLogging парсит конфигурацию логирования
-func (p *ComposeParser) parseLogging(raw interface{}) (*LoggingConfig, error) {
+func (p *ComposeParser) parseLogging(node *yaml.Node, path string) (*LoggingConfig, error) {
 	logging := &LoggingConfig{}
 
-	loggingMap, ok := raw.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("logging configuration must be a map")
-	}
-
-	if driver, ok := loggingMap["driver"].(string); ok {
-		logging.Driver = driver
+	d := p.newNodeDecoder(node, path)
+	logging.Driver = d.stringField("driver")
+	if d.has("options") {
+		logging.Options = d.keyValueField("options")
 	}
 
-	if optionsRaw, ok := loggingMap["options"]; ok {
-		options, err := p.parseLabels(optionsRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse logging options: %v", err)
-		}
-		logging.Options = options
+	if err := d.Err(); err != nil {
+		return nil, err
 	}
 
 	return logging, nil
 }
 
 // parseHealthcheck парсит конфигурацию проверки здоровья
-func (p *ComposeParser) parseHealthcheck(raw interface{}) (*HealthCheckConfig, error) {
+func (p *ComposeParser) parseHealthcheck(node *yaml.Node, path string) (*HealthCheckConfig, error) {
 	healthcheck := &HealthCheckConfig{}
 
-	healthcheckMap, ok := raw.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("healthcheck configuration must be a map")
-	}
-
-	if testRaw, ok := healthcheckMap["test"]; ok {
-		healthcheck.Test = p.parseStringOrSlice(testRaw)
-	}
-
-	if interval, ok := healthcheckMap["interval"].(string); ok {
-		healthcheck.Interval = interval
-	}
-
-	if timeout, ok := healthcheckMap["timeout"].(string); ok {
-		healthcheck.Timeout = timeout
-	}
-
-	if retries, ok := healthcheckMap["retries"].(int); ok {
-		healthcheck.Retries = uint64(retries)
-	}
+	d := p.newNodeDecoder(node, path)
+	healthcheck.Test = d.stringListField("test")
+	healthcheck.Interval = d.stringField("interval")
+	healthcheck.Timeout = d.stringField("timeout")
+	healthcheck.Retries = d.uintField("retries")
+	healthcheck.StartPeriod = d.stringField("start_period")
+	healthcheck.StartInterval = d.stringField("start_interval")
 
-	if startPeriod, ok := healthcheckMap["start_period"].(string); ok {
-		healthcheck.StartPeriod = startPeriod
-	}
-
-	if startInterval, ok := healthcheckMap["start_interval"].(string); ok {
-		healthcheck.StartInterval = startInterval
+	if err := d.Err(); err != nil {
+		return nil, err
 	}
 
 	return healthcheck, nil
 }
 
-// parseLabels парсит метки
-func (p *ComposeParser) parseLabels(raw interface{}) (map[string]string, error) {
-	labels := make(map[string]string)
-
-	switch v := raw.(type) {
-	case []interface{}:
-		for _, labelRaw := range v {
-			if labelStr, ok := labelRaw.(string); ok {
-				parts := strings.SplitN(labelStr, "=", 2)
-				if len(parts) == 2 {
-					labels[parts[0]] = parts[1]
-				}
-			}
-		}
-	case map[string]interface{}:
-		for key, value := range v {
-			if strValue, ok := value.(string); ok {
-				labels[key] = strValue
-			} else {
-				labels[key] = fmt.Sprintf("%v", value)
-			}
-		}
-	default:
-		return nil, fmt.Errorf("invalid labels configuration type: %T", raw)
-	}
-
-	return labels, nil
-}
-
 // parseExtends парсит конфигурацию расширения
-func (p *ComposeParser) parseExtends(raw interface{}) (*ExtendsConfig, error) {
+func (p *ComposeParser) parseExtends(node *yaml.Node, path string) (*ExtendsConfig, error) {
 	extends := &ExtendsConfig{}
 
-	switch v := raw.(type) {
-	case string:
-		extends.Service = v
-	case map[string]interface{}:
-		if file, ok := v["file"].(string); ok {
-			extends.File = file
-		}
-		if service, ok := v["services"].(string); ok {
-			extends.Service = service
-		}
-	default:
-		return nil, fmt.Errorf("invalid extends configuration type: %T", raw)
+	// Короткая форма содержит только имя сервиса
+	if node.Kind == yaml.ScalarNode {
+		extends.Service = node.Value
+		return extends, nil
+	}
+
+	d := p.newNodeDecoder(node, path)
+	extends.File = d.stringField("file")
+	extends.Service = d.stringField("service")
+
+	if err := d.Err(); err != nil {
+		return nil, err
 	}
 
 	return extends, nil
 }
 
-// parseNetwork парсит конфигурацию сети
-func (p *ComposeParser) parseNetwork(name string, raw interface{}) (*NetworkConfig, error) {
-	network := &NetworkConfig{}
-
-	networkMap, ok := raw.(map[string]interface{})
-	if !ok {
-		// Если это просто строка или булево значение (например, external: true)
-		if external, ok := raw.(bool); ok {
-			network.External = external
+// parseExternal парсит поле external ресурса. Устаревшая форма external: {name: ...}
+// помечает ресурс внешним и задает его имя
+func (p *ComposeParser) parseExternal(d *nodeDecoder) (bool, string) {
+	var external bool
+	var name string
+	d.field("external", func(node *yaml.Node, path string) (err error) {
+		if node.Kind == yaml.MappingNode {
+			external = true
+			name, err = decodeString(mappingValue(node, "name"), joinPath(path, "name"))
+			return err
 		}
-		return network, nil
-	}
+		external, err = p.decodeBool(node, path)
+		return err
+	})
+	return external, name
+}
 
-	if driver, ok := networkMap["driver"].(string); ok {
-		network.Driver = driver
-	}
+// parseNetwork парсит конфигурацию сети
+func (p *ComposeParser) parseNetwork(ctx *parseContext, name string, node *yaml.Node, path string) (*NetworkConfig, error) {
+	network := &NetworkConfig{Location: ctx.locationAt(path)}
 
-	if driverOptsRaw, ok := networkMap["driver_opts"]; ok {
-		driverOpts, err := p.parseLabels(driverOptsRaw)
+	// Если это булево значение (например, external: true)
+	if node.Kind == yaml.ScalarNode && !isNullNode(node) {
+		external, err := p.decodeBool(node, path)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse driver options: %v", err)
+			return nil, err
 		}
-		network.DriverOpts = driverOpts
-	}
-
-	if external, ok := networkMap["external"].(bool); ok {
 		network.External = external
+		return network, nil
 	}
 
-	if networkName, ok := networkMap["name"].(string); ok {
-		network.Name = networkName
-	}
+	d := p.newNodeDecoder(node, path)
+	network.Driver = d.stringField("driver")
+	network.DriverOpts = d.keyValueField("driver_opts")
 
-	if attachable, ok := networkMap["attachable"].(bool); ok {
-		network.Attachable = attachable
+	var externalName string
+	network.External, externalName = p.parseExternal(d)
+
+	network.Name = d.stringField("name")
+	if network.Name == "" {
+		network.Name = externalName
 	}
 
-	if internal, ok := networkMap["internal"].(bool); ok {
-		network.Internal = internal
+	network.Attachable = d.boolField("attachable")
+	network.Internal = d.boolField("internal")
+	if d.has("enable_ipv4") {
+		value := d.boolField("enable_ipv4")
+		network.EnableIPv4 = &value
+	}
+	if d.has("enable_ipv6") {
+		value := d.boolField("enable_ipv6")
+		network.EnableIPv6 = &value
 	}
+	d.field("ipam", func(node *yaml.Node, path string) (err error) {
+		network.IPAM, err = p.parseIPAM(node, path)
+		return err
+	})
+	network.Labels = d.keyValueField("labels")
+	network.Extensions, network.ExtensionNodes = d.extensions()
 
-	if labelsRaw, ok := networkMap["labels"]; ok {
-		labels, err := p.parseLabels(labelsRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse network labels: %v", err)
-		}
-		network.Labels = labels
+	if err := d.Err(); err != nil {
+		return nil, err
 	}
 
 	return network, nil
 }
 
 // parseVolume парсит конфигурацию тома
-func (p *ComposeParser) parseVolume(name string, raw interface{}) (*VolumeConfig, error) {
-	volume := &VolumeConfig{}
-
-	volumeMap, ok := raw.(map[string]interface{})
-	if !ok {
-		// Если это просто строка или булево значение (например, external: true)
-		if external, ok := raw.(bool); ok {
-			volume.External = external
+func (p *ComposeParser) parseVolume(ctx *parseContext, name string, node *yaml.Node, path string) (*VolumeConfig, error) {
+	volume := &VolumeConfig{Location: ctx.locationAt(path)}
+
+	// Если это булево значение (например, external: true)
+	if node.Kind == yaml.ScalarNode && !isNullNode(node) {
+		external, err := p.decodeBool(node, path)
+		if err != nil {
+			return nil, err
 		}
+		volume.External = external
 		return volume, nil
 	}
 
-	if driver, ok := volumeMap["driver"].(string); ok {
-		volume.Driver = driver
-	}
+	d := p.newNodeDecoder(node, path)
+	volume.Driver = d.stringField("driver")
+	volume.DriverOpts = d.keyValueField("driver_opts")
 
-	if driverOptsRaw, ok := volumeMap["driver_opts"]; ok {
-		driverOpts, err := p.parseLabels(driverOptsRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse driver options: %v", err)
-		}
-		volume.DriverOpts = driverOpts
-	}
+	var externalName string
+	volume.External, externalName = p.parseExternal(d)
 
-	if external, ok := volumeMap["external"].(bool); ok {
-		volume.External = external
+	volume.Name = d.stringField("name")
+	if volume.Name == "" {
+		volume.Name = externalName
 	}
 
-	if volumeName, ok := volumeMap["name"].(string); ok {
-		volume.Name = volumeName
-	}
+	volume.Labels = d.keyValueField("labels")
+	volume.Extensions, volume.ExtensionNodes = d.extensions()
 
-	if labelsRaw, ok := volumeMap["labels"]; ok {
-		labels, err := p.parseLabels(labelsRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse volume labels: %v", err)
-		}
-		volume.Labels = labels
+	if err := d.Err(); err != nil {
+		return nil, err
 	}
 
 	return volume, nil
 }
 
 // parseSecret парсит конфигурацию секрета
-func (p *ComposeParser) parseSecret(name string, raw interface{}) (*SecretConfig, error) {
-	secret := &SecretConfig{}
-
-	secretMap, ok := raw.(map[string]interface{})
-	if !ok {
-		// Если это просто строка или булево значение
-		if external, ok := raw.(bool); ok {
-			secret.External = external
-		} else if file, ok := raw.(string); ok {
-			secret.File = file
+func (p *ComposeParser) parseSecret(ctx *parseContext, name string, node *yaml.Node, path string) (*SecretConfig, error) {
+	secret := &SecretConfig{Location: ctx.locationAt(path)}
+
+	// Если это просто строка или булево значение
+	if node.Kind == yaml.ScalarNode && !isNullNode(node) {
+		if node.ShortTag() == "!!bool" {
+			secret.External, _ = p.decodeBool(node, path)
+		} else {
+			secret.File = node.Value
 		}
 		return secret, nil
 	}
 
-	if file, ok := secretMap["file"].(string); ok {
-		secret.File = file
-	}
+	d := p.newNodeDecoder(node, path)
+	secret.File = d.stringField("file")
 
-	if external, ok := secretMap["external"].(bool); ok {
-		secret.External = external
-	}
+	var externalName string
+	secret.External, externalName = p.parseExternal(d)
 
-	if secretName, ok := secretMap["name"].(string); ok {
-		secret.Name = secretName
+	secret.Name = d.stringField("name")
+	if secret.Name == "" {
+		secret.Name = externalName
 	}
 
-	if labelsRaw, ok := secretMap["labels"]; ok {
-		labels, err := p.parseLabels(labelsRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse secret labels: %v", err)
-		}
-		secret.Labels = labels
+	secret.Labels = d.keyValueField("labels")
+	secret.Extensions, secret.ExtensionNodes = d.extensions()
+
+	if err := d.Err(); err != nil {
+		return nil, err
 	}
 
 	return secret, nil
 }
 
 // parseConfig парсит конфигурацию конфигурации
-func (p *ComposeParser) parseConfig(name string, raw interface{}) (*ConfigConfig, error) {
-	config := &ConfigConfig{}
-
-	configMap, ok := raw.(map[string]interface{})
-	if !ok {
-		// Если это просто строка или булево значение
-		if external, ok := raw.(bool); ok {
-			config.External = external
-		} else if file, ok := raw.(string); ok {
-			config.File = file
+func (p *ComposeParser) parseConfig(ctx *parseContext, name string, node *yaml.Node, path string) (*ConfigConfig, error) {
+	config := &ConfigConfig{Location: ctx.locationAt(path)}
+
+	// Если это просто строка или булево значение
+	if node.Kind == yaml.ScalarNode && !isNullNode(node) {
+		if node.ShortTag() == "!!bool" {
+			config.External, _ = p.decodeBool(node, path)
+		} else {
+			config.File = node.Value
 		}
 		return config, nil
 	}
 
-	if file, ok := configMap["file"].(string); ok {
-		config.File = file
-	}
+	d := p.newNodeDecoder(node, path)
+	config.File = d.stringField("file")
 
-	if external, ok := configMap["external"].(bool); ok {
-		config.External = external
-	}
+	var externalName string
+	config.External, externalName = p.parseExternal(d)
 
-	if configName, ok := configMap["name"].(string); ok {
-		config.Name = configName
+	config.Name = d.stringField("name")
+	if config.Name == "" {
+		config.Name = externalName
 	}
 
-	if labelsRaw, ok := configMap["labels"]; ok {
-		labels, err := p.parseLabels(labelsRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse config labels: %v", err)
-		}
-		config.Labels = labels
+	config.Labels = d.keyValueField("labels")
+	config.Extensions, config.ExtensionNodes = d.extensions()
+
+	if err := d.Err(); err != nil {
+		return nil, err
 	}
 
 	return config, nil
 }
 
-// parseStringOrSlice парсит строку или срез строк
-func (p *ComposeParser) parseStringOrSlice(raw interface{}) []string {
-	switch v := raw.(type) {
-	case string:
-		return []string{v}
-	case []interface{}:
-		var result []string
-		for _, item := range v {
-			if str, ok := item.(string); ok {
-				result = append(result, str)
-			}
-		}
-		return result
-	default:
-		return nil
-	}
-}
-
-// ParseReader парсит Docker Compose файл из io.Reader
+// ParseReader парсит Docker Compose файл из io.Reader. Имя проекта определяется как в ParseYAML
 func (p *ComposeParser) ParseReader(reader io.Reader) (*ComposeProjectConfig, error) {
-	return p.ParseReaderWithName(reader, "docker-compose")
+	return p.ParseReaderWithName(reader, "")
 }
 
 // ParseReaderWithName парсит Docker Compose файл из io.Reader с указанным именем проекта
 func (p *ComposeParser) ParseReaderWithName(reader io.Reader, projectName string) (*ComposeProjectConfig, error) {
 	data, err := io.ReadAll(reader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read from reader: %v", err)
+		return nil, fmt.Errorf("failed to read from reader: %w", err)
 	}
 
 	return p.parseYAML(data, projectName)
@@ -1234,7 +1112,7 @@ func (p *ComposeParser) ParseFromDirectory(dirPath string) ([]*ComposeProjectCon
 		if _, err := os.Stat(filePath); err == nil {
 			project, err := p.ParseFile(filePath)
 			if err != nil {
-				return nil, fmt.Errorf("failed to parse file %s: %v", filePath, err)
+				return nil, fmt.Errorf("failed to parse file %s: %w", filePath, err)
 			}
 			projects = append(projects, project)
 		}
@@ -0,0 +1,298 @@
+package compose_parser
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// pullPolicies содержит допустимые значения pull_policy, кроме every_<длительность>
+var pullPolicies = map[string]bool{
+	"always":         true,
+	"never":          true,
+	"missing":        true,
+	"if_not_present": true,
+	"build":          true,
+	"daily":          true,
+	"weekly":         true,
+}
+
+// parseContainerOptions парсит параметры контейнера сервиса: хост, DNS, устройства,
+// ограничения ресурсов, безопасность, пространства имен, запуск и остановку
+func (p *ComposeParser) parseContainerOptions(d *nodeDecoder, service *ComposeServiceConfig) {
+	// Хост и DNS
+	service.Hostname = d.stringField("hostname")
+	service.Domainname = d.stringField("domainname")
+	service.DNS = d.stringListField("dns")
+	service.DNSSearch = d.stringListField("dns_search")
+	service.DNSOpt = d.stringListField("dns_opt")
+	d.field("extra_hosts", func(node *yaml.Node, path string) (err error) {
+		service.ExtraHosts, err = parseExtraHosts(node, path)
+		return err
+	})
+
+	// Устройства и tmpfs
+	service.Tmpfs = d.stringListField("tmpfs")
+	d.field("devices", func(node *yaml.Node, path string) (err error) {
+		service.Devices, err = p.parseDevices(node, path)
+		return err
+	})
+
+	// Ограничения ресурсов
+	service.MemLimit = d.stringField("mem_limit")
+	service.MemReservation = d.stringField("mem_reservation")
+	service.ShmSize = d.stringField("shm_size")
+	d.field("oom_score_adj", func(node *yaml.Node, path string) (err error) {
+		service.OomScoreAdj, err = p.decodeInt(node, path)
+		if err == nil && (service.OomScoreAdj < -1000 || service.OomScoreAdj > 1000) {
+			err = invalidValueError(node, path, "must be between -1000 and 1000")
+		}
+		return err
+	})
+	d.field("ulimits", func(node *yaml.Node, path string) (err error) {
+		service.Ulimits, err = p.parseUlimits(node, path)
+		return err
+	})
+	service.Sysctls = d.keyValueField("sysctls")
+
+	// Безопасность и пространства имен
+	service.Privileged = d.boolField("privileged")
+	service.ReadOnly = d.boolField("read_only")
+	service.CapAdd = d.stringListField("cap_add")
+	service.CapDrop = d.stringListField("cap_drop")
+	service.SecurityOpt = d.stringListField("security_opt")
+	service.GroupAdd = d.stringListField("group_add")
+	service.Ipc = d.stringField("ipc")
+	service.Pid = d.stringField("pid")
+	service.UsernsMode = d.stringField("userns_mode")
+
+	// Запуск и остановка
+	if d.has("init") {
+		value := d.boolField("init")
+		service.Init = &value
+	}
+	service.Tty = d.boolField("tty")
+	service.StdinOpen = d.boolField("stdin_open")
+	service.StopSignal = d.stringField("stop_signal")
+	service.StopGracePeriod = d.stringField("stop_grace_period")
+	d.field("pull_policy", func(node *yaml.Node, path string) (err error) {
+		service.PullPolicy, err = p.decodePullPolicy(node, path)
+		return err
+	})
+	if d.has("scale") {
+		value := d.uintField("scale")
+		service.Scale = &value
+	}
+	if d.has("attach") {
+		value := d.boolField("attach")
+		service.Attach = &value
+	}
+	service.Runtime = d.stringField("runtime")
+	service.Annotations = d.keyValueField("annotations")
+}
+
+// decodePullPolicy декодирует политику загрузки образа
+func (p *ComposeParser) decodePullPolicy(node *yaml.Node, path string) (string, error) {
+	policy, err := decodeString(node, path)
+	if err != nil || policy == "" || p.isUnresolved(node) {
+		return policy, err
+	}
+
+	if pullPolicies[policy] {
+		return policy, nil
+	}
+	if interval, ok := strings.CutPrefix(policy, "every_"); ok && interval != "" {
+		return policy, nil
+	}
+	return "", invalidValueError(node, path,
+		"invalid pull policy %q, must be one of always, never, missing, build, daily, weekly or every_<duration>", policy)
+}
+
+// parseExtraHosts парсит дополнительные записи /etc/hosts: список "host:ip" или
+// "host=ip" либо отображение имени хоста на адрес или список адресов
+func parseExtraHosts(node *yaml.Node, path string) (map[string][]string, error) {
+	hosts := make(map[string][]string)
+	if isNullNode(node) {
+		return hosts, nil
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		err := forEachEntry(node, path, func(host string, value *yaml.Node, path string) error {
+			addresses, err := decodeStringList(value, path)
+			if err != nil {
+				return err
+			}
+			for _, address := range addresses {
+				hosts[host] = append(hosts[host], trimHostAddress(address))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	case yaml.SequenceNode:
+		err := forEachItem(node, path, func(item *yaml.Node, path string) error {
+			entry, err := decodeString(item, path)
+			if err != nil {
+				return err
+			}
+			// Адрес IPv6 содержит ":", поэтому "=" имеет приоритет как разделитель
+			host, address, ok := strings.Cut(entry, "=")
+			if !ok {
+				host, address, ok = strings.Cut(entry, ":")
+			}
+			if !ok || host == "" || address == "" {
+				return invalidValueError(item, path, "invalid extra host %q, expected host:ip or host=ip", entry)
+			}
+			hosts[host] = append(hosts[host], trimHostAddress(address))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, newTypeError(node, path, "a mapping or a list of host:ip strings")
+	}
+
+	return hosts, nil
+}
+
+// trimHostAddress убирает квадратные скобки вокруг адреса IPv6: [::1] -> ::1
+func trimHostAddress(address string) string {
+	if strings.HasPrefix(address, "[") && strings.HasSuffix(address, "]") {
+		return address[1 : len(address)-1]
+	}
+	return address
+}
+
+// parseDevices парсит устройства сервиса в короткой ("/dev/src[:/dev/dst[:rwm]]")
+// или длинной (source, target, permissions) форме
+func (p *ComposeParser) parseDevices(node *yaml.Node, path string) ([]DeviceMapping, error) {
+	var devices []DeviceMapping
+	err := forEachItem(node, path, func(item *yaml.Node, path string) error {
+		if item.Kind == yaml.MappingNode {
+			d := p.newNodeDecoder(item, path)
+			device := DeviceMapping{
+				Source:      d.stringField("source"),
+				Target:      d.stringField("target"),
+				Permissions: d.stringField("permissions"),
+			}
+			if err := d.Err(); err != nil {
+				return err
+			}
+			if device.Source == "" {
+				return requiredFieldError(item, path, "source")
+			}
+			if device.Target == "" {
+				device.Target = device.Source
+			}
+			devices = append(devices, device)
+			return nil
+		}
+
+		value, err := decodeString(item, path)
+		if err != nil {
+			return err
+		}
+		device, err := parseDeviceString(value)
+		if err != nil {
+			return invalidValueError(item, path, "%v", err)
+		}
+		devices = append(devices, device)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+// parseDeviceString парсит короткую форму устройства. Если путь в контейнере
+// не указан, он совпадает с путем на хосте
+func parseDeviceString(value string) (DeviceMapping, error) {
+	// CDI устройство задается полным именем vendor.com/class=name
+	if !strings.HasPrefix(value, "/") && strings.Contains(value, "=") {
+		return DeviceMapping{Source: value}, nil
+	}
+
+	parts := strings.Split(value, ":")
+	device := DeviceMapping{Source: parts[0], Target: parts[0]}
+	switch len(parts) {
+	case 1:
+	case 2:
+		if isDevicePermissions(parts[1]) {
+			device.Permissions = parts[1]
+		} else {
+			device.Target = parts[1]
+		}
+	case 3:
+		if !isDevicePermissions(parts[2]) {
+			return DeviceMapping{}, invalidDeviceError(value)
+		}
+		device.Target = parts[1]
+		device.Permissions = parts[2]
+	default:
+		return DeviceMapping{}, invalidDeviceError(value)
+	}
+
+	if device.Source == "" || device.Target == "" {
+		return DeviceMapping{}, invalidDeviceError(value)
+	}
+	return device, nil
+}
+
+// invalidDeviceError создает ошибку некорректной записи устройства
+func invalidDeviceError(value string) error {
+	return fmt.Errorf("invalid device %q, expected /dev/src[:/dev/dst[:rwm]]", value)
+}
+
+// isDevicePermissions проверяет, что строка состоит из прав доступа r, w, m
+func isDevicePermissions(value string) bool {
+	if value == "" || len(value) > 3 {
+		return false
+	}
+	return strings.Trim(value, "rwm") == ""
+}
+
+// parseUlimits парсит ограничения ресурсов: число задает мягкое и жесткое
+// ограничение одновременно, отображение задает soft и hard отдельно
+func (p *ComposeParser) parseUlimits(node *yaml.Node, path string) (map[string]*UlimitConfig, error) {
+	ulimits := make(map[string]*UlimitConfig)
+	err := forEachEntry(node, path, func(name string, value *yaml.Node, path string) error {
+		if value.Kind != yaml.MappingNode {
+			single, err := p.decodeInt(value, path)
+			if err != nil {
+				return err
+			}
+			ulimits[name] = &UlimitConfig{Single: single}
+			return nil
+		}
+
+		d := p.newNodeDecoder(value, path)
+		ulimit := &UlimitConfig{
+			Soft: d.intField("soft"),
+			Hard: d.intField("hard"),
+		}
+		if err := d.Err(); err != nil {
+			return err
+		}
+		for _, field := range []string{"soft", "hard"} {
+			if !d.has(field) {
+				return requiredFieldError(value, path, field)
+			}
+		}
+		if ulimit.Soft > ulimit.Hard {
+			return invalidValueError(value, path, "soft limit %d exceeds hard limit %d", ulimit.Soft, ulimit.Hard)
+		}
+		ulimits[name] = ulimit
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ulimits, nil
+}
@@ -0,0 +1,458 @@
+package compose_parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// nodeDecoder читает поля узла-отображения с приведением типов по схеме Compose.
+// Первая ошибка сохраняется и возвращается методом Err, после нее чтение полей
+// возвращает нулевые значения. Если задан report, ошибки полей передаются в него,
+// а чтение остальных полей продолжается
+type nodeDecoder struct {
+	parser *ComposeParser
+	node   *yaml.Node
+	path   string
+	err    error
+	report func(err error)
+}
+
+// newNodeDecoder создает декодер узла-отображения. Пустое значение (null)
+// считается пустым отображением
+func (p *ComposeParser) newNodeDecoder(node *yaml.Node, path string) *nodeDecoder {
+	d := &nodeDecoder{parser: p, node: node, path: path}
+	if !isNullNode(node) && node.Kind != yaml.MappingNode {
+		d.err = newTypeError(node, path, "a mapping")
+	}
+	return d
+}
+
+// Err возвращает первую ошибку декодирования
+func (d *nodeDecoder) Err() error {
+	return d.err
+}
+
+// fail сохраняет ошибку поля или передает ее в report
+func (d *nodeDecoder) fail(err error) {
+	if d.report != nil {
+		d.report(err)
+		return
+	}
+	d.err = err
+}
+
+// value возвращает узел значения поля или nil, если поле отсутствует или равно null
+func (d *nodeDecoder) value(key string) *yaml.Node {
+	if d.err != nil {
+		return nil
+	}
+	node := mappingValue(d.node, key)
+	if isNullNode(node) {
+		return nil
+	}
+	return node
+}
+
+// has проверяет, задано ли поле
+func (d *nodeDecoder) has(key string) bool {
+	return d.value(key) != nil
+}
+
+// fieldPath возвращает путь поля для сообщений об ошибках
+func (d *nodeDecoder) fieldPath(key string) string {
+	return joinPath(d.path, key)
+}
+
+// field вызывает decode для заданного поля и сохраняет ошибку
+func (d *nodeDecoder) field(key string, decode func(node *yaml.Node, path string) error) {
+	node := d.value(key)
+	if node == nil {
+		return
+	}
+	if err := decode(node, d.fieldPath(key)); err != nil {
+		d.fail(err)
+	}
+}
+
+// stringField читает строковое поле
+func (d *nodeDecoder) stringField(key string) string {
+	var result string
+	d.field(key, func(node *yaml.Node, path string) (err error) {
+		result, err = decodeString(node, path)
+		return err
+	})
+	return result
+}
+
+// boolField читает логическое поле
+func (d *nodeDecoder) boolField(key string) bool {
+	var result bool
+	d.field(key, func(node *yaml.Node, path string) (err error) {
+		result, err = d.parser.decodeBool(node, path)
+		return err
+	})
+	return result
+}
+
+// intField читает целочисленное поле
+func (d *nodeDecoder) intField(key string) int64 {
+	var result int64
+	d.field(key, func(node *yaml.Node, path string) (err error) {
+		result, err = d.parser.decodeInt(node, path)
+		return err
+	})
+	return result
+}
+
+// uintField читает неотрицательное целочисленное поле
+func (d *nodeDecoder) uintField(key string) uint64 {
+	var result uint64
+	d.field(key, func(node *yaml.Node, path string) (err error) {
+		result, err = d.parser.decodeUint(node, path)
+		return err
+	})
+	return result
+}
+
+// floatField читает числовое поле
+func (d *nodeDecoder) floatField(key string) float64 {
+	var result float64
+	d.field(key, func(node *yaml.Node, path string) (err error) {
+		result, err = d.parser.decodeFloat(node, path)
+		return err
+	})
+	return result
+}
+
+// numberField читает числовое поле, которое хранится в модели строкой (например, cpus: 0.5)
+func (d *nodeDecoder) numberField(key string) string {
+	var result string
+	d.field(key, func(node *yaml.Node, path string) (err error) {
+		result, err = d.parser.decodeNumberString(node, path)
+		return err
+	})
+	return result
+}
+
+// stringListField читает строку или список строк
+func (d *nodeDecoder) stringListField(key string) []string {
+	var result []string
+	d.field(key, func(node *yaml.Node, path string) (err error) {
+		result, err = decodeStringList(node, path)
+		return err
+	})
+	return result
+}
+
+// keyValueField читает отображение строк или список "ключ=значение"
+func (d *nodeDecoder) keyValueField(key string) map[string]string {
+	var result map[string]string
+	d.field(key, func(node *yaml.Node, path string) (err error) {
+		result, err = decodeKeyValues(node, path)
+		return err
+	})
+	return result
+}
+
+// decodeString декодирует скалярное значение как строку.
+// Числа и логические значения сохраняются в том виде, в котором записаны в файле
+func decodeString(node *yaml.Node, path string) (string, error) {
+	if isNullNode(node) {
+		return "", nil
+	}
+	if node.Kind != yaml.ScalarNode {
+		return "", newTypeError(node, path, "a string")
+	}
+	return node.Value, nil
+}
+
+// decodeBool декодирует логическое значение. Строки true/false, yes/no, on/off
+// допускаются, так как интерполяция возвращает строки
+func (p *ComposeParser) decodeBool(node *yaml.Node, path string) (bool, error) {
+	if isNullNode(node) || p.isUnresolved(node) {
+		return false, nil
+	}
+	if node.Kind != yaml.ScalarNode {
+		return false, newTypeError(node, path, "a boolean")
+	}
+	switch strings.ToLower(node.Value) {
+	case "true", "yes", "y", "on":
+		return true, nil
+	case "false", "no", "n", "off":
+		return false, nil
+	default:
+		return false, newTypeError(node, path, "a boolean")
+	}
+}
+
+// decodeInt декодирует целое число из числового или строкового значения
+func (p *ComposeParser) decodeInt(node *yaml.Node, path string) (int64, error) {
+	if isNullNode(node) || p.isUnresolved(node) {
+		return 0, nil
+	}
+	if node.Kind != yaml.ScalarNode {
+		return 0, newTypeError(node, path, "an integer")
+	}
+
+	switch node.ShortTag() {
+	case "!!int":
+		var value int64
+		if err := node.Decode(&value); err != nil {
+			return 0, newTypeError(node, path, "an integer")
+		}
+		return value, nil
+	case "!!str":
+		value, err := strconv.ParseInt(strings.TrimSpace(node.Value), 10, 64)
+		if err != nil {
+			return 0, newTypeError(node, path, "an integer")
+		}
+		return value, nil
+	default:
+		return 0, newTypeError(node, path, "an integer")
+	}
+}
+
+// decodeUint декодирует неотрицательное целое число
+func (p *ComposeParser) decodeUint(node *yaml.Node, path string) (uint64, error) {
+	value, err := p.decodeInt(node, path)
+	if err != nil {
+		return 0, err
+	}
+	if value < 0 {
+		return 0, newTypeError(node, path, "a non-negative integer")
+	}
+	return uint64(value), nil
+}
+
+// decodeFloat декодирует число из целого, дробного или строкового значения
+func (p *ComposeParser) decodeFloat(node *yaml.Node, path string) (float64, error) {
+	if isNullNode(node) || p.isUnresolved(node) {
+		return 0, nil
+	}
+	if node.Kind != yaml.ScalarNode {
+		return 0, newTypeError(node, path, "a number")
+	}
+
+	switch node.ShortTag() {
+	case "!!int", "!!float":
+		var value float64
+		if err := node.Decode(&value); err != nil {
+			return 0, newTypeError(node, path, "a number")
+		}
+		return value, nil
+	case "!!str":
+		value, err := strconv.ParseFloat(strings.TrimSpace(node.Value), 64)
+		if err != nil {
+			return 0, newTypeError(node, path, "a number")
+		}
+		return value, nil
+	default:
+		return 0, newTypeError(node, path, "a number")
+	}
+}
+
+// decodeNumberString декодирует число и возвращает его строковую запись
+func (p *ComposeParser) decodeNumberString(node *yaml.Node, path string) (string, error) {
+	if isNullNode(node) {
+		return "", nil
+	}
+	if p.isUnresolved(node) {
+		return node.Value, nil
+	}
+	if _, err := p.decodeFloat(node, path); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(node.Value), nil
+}
+
+// decodeStringList декодирует строку или список скалярных значений
+func decodeStringList(node *yaml.Node, path string) ([]string, error) {
+	if isNullNode(node) {
+		return nil, nil
+	}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return []string{node.Value}, nil
+	case yaml.SequenceNode:
+		result := make([]string, 0, len(node.Content))
+		for i, item := range node.Content {
+			value, err := decodeString(item, indexPath(path, i))
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+		}
+		return result, nil
+	default:
+		return nil, newTypeError(node, path, "a string or a list of strings")
+	}
+}
+
+// decodeKeyValues декодирует отображение скалярных значений или список "ключ=значение".
+// Ключ без значения получает пустую строку
+func decodeKeyValues(node *yaml.Node, path string) (map[string]string, error) {
+	result := make(map[string]string)
+	if isNullNode(node) {
+		return result, nil
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			value, err := decodeString(node.Content[i+1], joinPath(path, key))
+			if err != nil {
+				return nil, err
+			}
+			result[key] = value
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			entry, err := decodeString(item, indexPath(path, i))
+			if err != nil {
+				return nil, err
+			}
+			key, value, _ := strings.Cut(entry, "=")
+			result[key] = value
+		}
+	default:
+		return nil, newTypeError(node, path, "a mapping or a list of key=value strings")
+	}
+
+	return result, nil
+}
+
+// isUnresolved проверяет, содержит ли значение неподставленную переменную.
+// Такие значения допускаются при отключенной интерполяции и декодируются как нулевые
+func (p *ComposeParser) isUnresolved(node *yaml.Node) bool {
+	return p.disableInterpolate && node.Kind == yaml.ScalarNode && strings.Contains(node.Value, "$")
+}
+
+// isNullNode проверяет, отсутствует ли значение узла
+func isNullNode(node *yaml.Node) bool {
+	return node == nil || (node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null")
+}
+
+// joinPath добавляет ключ к пути поля: services.web.ports
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// indexPath добавляет индекс элемента списка к пути поля: services.web.ports[2]
+func indexPath(path string, index int) string {
+	return fmt.Sprintf("%s[%d]", path, index)
+}
+
+// describeNode возвращает описание типа и значения узла для сообщений об ошибках
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "mapping"
+	case yaml.SequenceNode:
+		return "list"
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!int":
+			return "integer " + node.Value
+		case "!!float":
+			return "number " + node.Value
+		case "!!bool":
+			return "boolean " + node.Value
+		case "!!null":
+			return "null"
+		default:
+			return strconv.Quote(node.Value)
+		}
+	default:
+		return "unsupported value"
+	}
+}
+
+// forEachEntry вызывает fn для каждой пары ключ-значение узла-отображения в порядке документа.
+// Пустое значение (null) считается пустым отображением
+func forEachEntry(node *yaml.Node, path string, fn func(key string, value *yaml.Node, path string) error) error {
+	if isNullNode(node) {
+		return nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return newTypeError(node, path, "a mapping")
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if keyNode.Kind != yaml.ScalarNode {
+			return newTypeError(keyNode, path, "a mapping with string keys")
+		}
+		if err := fn(keyNode.Value, node.Content[i+1], joinPath(path, keyNode.Value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forEachItem вызывает fn для каждого элемента узла-списка
+func forEachItem(node *yaml.Node, path string, fn func(item *yaml.Node, path string) error) error {
+	if isNullNode(node) {
+		return nil
+	}
+	if node.Kind != yaml.SequenceNode {
+		return newTypeError(node, path, "a list")
+	}
+	for i, item := range node.Content {
+		if err := fn(item, indexPath(path, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extensions возвращает значения полей расширений (ключи с префиксом "x-").
+// Исходные узлы возвращаются, только если включена опция WithExtensionNodes
+func (d *nodeDecoder) extensions() (map[string]interface{}, map[string]*yaml.Node) {
+	if d.err != nil || d.node == nil || d.node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	var values map[string]interface{}
+	var nodes map[string]*yaml.Node
+	for i := 0; i+1 < len(d.node.Content); i += 2 {
+		key := d.node.Content[i].Value
+		if !isExtensionKey(key) {
+			continue
+		}
+
+		var value interface{}
+		if err := d.node.Content[i+1].Decode(&value); err != nil {
+			d.fail(invalidValueError(d.node.Content[i+1], d.fieldPath(key), "%v", err))
+			if d.err != nil {
+				return nil, nil
+			}
+			continue
+		}
+
+		if values == nil {
+			values = make(map[string]interface{})
+		}
+		values[key] = value
+
+		if d.parser.extensionNodes {
+			if nodes == nil {
+				nodes = make(map[string]*yaml.Node)
+			}
+			nodes[key] = d.node.Content[i+1]
+		}
+	}
+
+	return values, nodes
+}
+
+// isExtensionKey проверяет, является ли ключ полем расширения
+func isExtensionKey(key string) bool {
+	return strings.HasPrefix(key, "x-")
+}
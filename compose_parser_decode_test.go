@@ -0,0 +1,259 @@
+package compose_parser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlValue разбирает YAML значение и возвращает его узел
+func yamlValue(t *testing.T, value string) *yaml.Node {
+	t.Helper()
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &document); err != nil {
+		t.Fatalf("invalid YAML %q: %v", value, err)
+	}
+	if len(document.Content) == 0 {
+		// Пустой документ соответствует null
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+	}
+	return document.Content[0]
+}
+
+func TestDecodeScalars(t *testing.T) {
+	parser := NewComposeParser()
+
+	boolTests := []struct {
+		value string
+		want  bool
+		ok    bool
+	}{
+		{"true", true, true},
+		{"false", false, true},
+		{`"yes"`, true, true},
+		{"off", false, true},
+		{`"TRUE"`, true, true},
+		{"null", false, true},
+		{"1", false, false},
+		{"[true]", false, false},
+	}
+	for _, tt := range boolTests {
+		got, err := parser.decodeBool(yamlValue(t, tt.value), "field")
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("decodeBool(%s) = %v, %v", tt.value, got, err)
+		}
+	}
+
+	intTests := []struct {
+		value string
+		want  int64
+		ok    bool
+	}{
+		{"512", 512, true},
+		{"-5", -5, true},
+		{"0x10", 16, true},
+		{`"1024"`, 1024, true},
+		{`" 42 "`, 42, true},
+		{"null", 0, true},
+		{"1.5", 0, false},
+		{`"abc"`, 0, false},
+		{"true", 0, false},
+		{"{a: 1}", 0, false},
+	}
+	for _, tt := range intTests {
+		got, err := parser.decodeInt(yamlValue(t, tt.value), "field")
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("decodeInt(%s) = %v, %v", tt.value, got, err)
+		}
+	}
+
+	uintTests := []struct {
+		value string
+		want  uint64
+		ok    bool
+	}{
+		{"3", 3, true},
+		{`"3"`, 3, true},
+		{"-1", 0, false},
+	}
+	for _, tt := range uintTests {
+		got, err := parser.decodeUint(yamlValue(t, tt.value), "field")
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("decodeUint(%s) = %v, %v", tt.value, got, err)
+		}
+	}
+
+	floatTests := []struct {
+		value string
+		want  float64
+		ok    bool
+	}{
+		{"0.5", 0.5, true},
+		{"2", 2, true},
+		{`"1.25"`, 1.25, true},
+		{`"x"`, 0, false},
+		{"false", 0, false},
+	}
+	for _, tt := range floatTests {
+		got, err := parser.decodeFloat(yamlValue(t, tt.value), "field")
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("decodeFloat(%s) = %v, %v", tt.value, got, err)
+		}
+	}
+
+	numberTests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{"0.5", "0.5", true},
+		{`"1.5"`, "1.5", true},
+		{"2", "2", true},
+		{`"half"`, "", false},
+	}
+	for _, tt := range numberTests {
+		got, err := parser.decodeNumberString(yamlValue(t, tt.value), "field")
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("decodeNumberString(%s) = %q, %v", tt.value, got, err)
+		}
+	}
+
+	modeTests := []struct {
+		value string
+		want  uint32
+		ok    bool
+	}{
+		{"0440", 0o440, true},
+		{"0o755", 0o755, true},
+		{`"0440"`, 0o440, true},
+		{`"755"`, 0o755, true},
+		{`"0o600"`, 0o600, true},
+		{"288", 288, true},
+		{`"800"`, 0, false},
+		{"0o17777", 0, false},
+		{"-1", 0, false},
+	}
+	for _, tt := range modeTests {
+		got, err := parser.decodeFileMode(yamlValue(t, tt.value), "field")
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("decodeFileMode(%s) = %o, %v", tt.value, got, err)
+		}
+	}
+}
+
+func TestDecodeCollections(t *testing.T) {
+	listTests := []struct {
+		value string
+		want  []string
+		ok    bool
+	}{
+		{"single", []string{"single"}, true},
+		{"[a, 1, true]", []string{"a", "1", "true"}, true},
+		{"null", nil, true},
+		{"[[a]]", nil, false},
+		{"{a: b}", nil, false},
+	}
+	for _, tt := range listTests {
+		got, err := decodeStringList(yamlValue(t, tt.value), "field")
+		if (err == nil) != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("decodeStringList(%s) = %q, %v", tt.value, got, err)
+		}
+	}
+
+	keyValueTests := []struct {
+		value string
+		want  map[string]string
+		ok    bool
+	}{
+		{"{a: b, n: 1}", map[string]string{"a": "b", "n": "1"}, true},
+		{"[a=b, c=d=e, f]", map[string]string{"a": "b", "c": "d=e", "f": ""}, true},
+		{"null", map[string]string{}, true},
+		{"text", nil, false},
+		{"{a: [b]}", nil, false},
+	}
+	for _, tt := range keyValueTests {
+		got, err := decodeKeyValues(yamlValue(t, tt.value), "field")
+		if (err == nil) != tt.ok || (tt.ok && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("decodeKeyValues(%s) = %v, %v", tt.value, got, err)
+		}
+	}
+}
+
+func TestDecodeServiceFields(t *testing.T) {
+	document := `services:
+  web:
+    image: app
+    cpu_shares: 512
+    cpus: 1.5
+    cpu_quota: "50000"
+    read_only: "true"
+    deploy:
+      replicas: 2
+      resources:
+        limits:
+          cpus: 0.5
+          memory: 256M
+          pids: 100
+`
+	project, err := NewComposeParser().ParseYAML([]byte(document))
+	if err != nil {
+		t.Fatalf("ParseYAML: %v", err)
+	}
+
+	web := project.Services["web"]
+	if web.CPUShares != 512 || web.CPUs != 1.5 || web.CPUQuota != 50000 || !web.ReadOnly {
+		t.Errorf("cpu_shares = %d, cpus = %v, cpu_quota = %d, read_only = %v", web.CPUShares, web.CPUs, web.CPUQuota, web.ReadOnly)
+	}
+	if web.Deploy == nil || web.Deploy.Resources == nil || web.Deploy.Resources.Limits == nil {
+		t.Fatalf("deploy = %+v", web.Deploy)
+	}
+	limits := web.Deploy.Resources.Limits
+	if limits.CPUs != "0.5" || limits.Memory != "256M" || limits.Pids != 100 {
+		t.Errorf("limits = %+v", limits)
+	}
+}
+
+func TestDecodeTypeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    string
+		wantPath string
+	}{
+		{"integer", "cpu_shares: lots", "services.web.cpu_shares"},
+		{"boolean", "read_only: maybe", "services.web.read_only"},
+		{"number", "cpus: [1]", "services.web.cpus"},
+		{"list", "dns: {a: b}", "services.web.dns"},
+		{"nested", "deploy:\n      resources:\n        limits:\n          pids: many", "services.web.deploy.resources.limits.pids"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := "services:\n  web:\n    image: app\n    " + tt.field + "\n"
+			_, err := NewComposeParser().ParseYAML([]byte(document))
+
+			var typeErr *TypeError
+			if !errors.As(err, &typeErr) {
+				t.Fatalf("error = %v, want a *TypeError", err)
+			}
+			if typeErr.Path != tt.wantPath || typeErr.Code != ErrCodeType || typeErr.Location == nil {
+				t.Errorf("path = %q, code = %q, location = %v", typeErr.Path, typeErr.Code, typeErr.Location)
+			}
+		})
+	}
+}
+
+func TestDecodeUnresolvedValues(t *testing.T) {
+	document := "services:\n  web:\n    image: app\n    cpu_shares: ${SHARES}\n    cpus: ${CPUS:-1}\n    read_only: $READ_ONLY\n"
+
+	project, err := NewComposeParser(WithoutInterpolation()).ParseYAML([]byte(document))
+	if err != nil {
+		t.Fatalf("ParseYAML without interpolation: %v", err)
+	}
+	// Неразрешенные переменные не считаются ошибкой типа и дают нулевое значение
+	web := project.Services["web"]
+	if web.CPUShares != 0 || web.CPUs != 0 || web.ReadOnly {
+		t.Errorf("cpu_shares = %d, cpus = %v, read_only = %v", web.CPUShares, web.CPUs, web.ReadOnly)
+	}
+}
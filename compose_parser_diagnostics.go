@@ -0,0 +1,140 @@
+package compose_parser
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Коды предупреждений
+const (
+	WarnCodeUnsetVariable = "unset_variable"            // Переменная не задана, подставлена пустая строка
+	WarnCodeLegacySchema  = "legacy_schema_unavailable" // Нет схемы для версии формата, использована спецификация Compose
+)
+
+// String возвращает описание диагностики с уровнем, путем и положением элемента
+func (d Diagnostic) String() string {
+	message := d.Message
+	if d.Path != "" {
+		message = d.Path + ": " + message
+	}
+	if d.Location != nil {
+		message += " (" + d.Location.String() + ")"
+	}
+	return d.Severity + ": " + message
+}
+
+// HasErrors проверяет, есть ли среди диагностик проекта ошибки
+func (c *ComposeProjectConfig) HasErrors() bool {
+	for _, diagnostic := range c.Diagnostics {
+		if diagnostic.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// newDiagnostic создает диагностику из ошибки парсера
+func newDiagnostic(severity string, err error) Diagnostic {
+	diagnostic := Diagnostic{Severity: severity, Message: err.Error(), Err: err}
+
+	var composeErr ComposeError
+	if errors.As(err, &composeErr) {
+		details := composeErr.Details()
+		diagnostic.Code = details.Code
+		diagnostic.Path = details.Path
+		diagnostic.Message = details.Message
+		diagnostic.Location = details.Location
+	}
+	return diagnostic
+}
+
+// diagnose добавляет ошибку в диагностики разбора. file используется
+// для положения ошибки, если файл ее узла неизвестен
+// Повторно найденная ошибка того же элемента не добавляется
+func (ctx *parseContext) diagnose(severity string, err error, file string) {
+	diagnostic := newDiagnostic(severity, ctx.completeError(err, file))
+	for _, existing := range ctx.diagnostics {
+		if existing.Path == diagnostic.Path && existing.Message == diagnostic.Message {
+			return
+		}
+	}
+	ctx.diagnostics = append(ctx.diagnostics, diagnostic)
+}
+
+// tolerate в режиме WithErrorTolerance добавляет ошибку в диагностики и возвращает nil,
+// чтобы разбор продолжился. В обычном режиме возвращает ошибку без изменений
+func (p *ComposeParser) tolerate(ctx *parseContext, err error, file string) error {
+	if err == nil || !p.tolerant {
+		return err
+	}
+	ctx.diagnose(SeverityError, err, file)
+	return nil
+}
+
+// sortDiagnostics упорядочивает диагностики по положению в исходных файлах.
+// Диагностики без положения (например, ошибки чтения файлов) идут первыми
+func sortDiagnostics(diagnostics []Diagnostic) []Diagnostic {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i].Location, diagnostics[j].Location
+		switch {
+		case a == nil || b == nil:
+			return a == nil && b != nil
+		case a.File != b.File:
+			return a.File < b.File
+		case a.Line != b.Line:
+			return a.Line < b.Line
+		default:
+			return a.Column < b.Column
+		}
+	})
+	return diagnostics
+}
+
+// errorService создает сервис-заглушку для сервиса, который не удалось разобрать
+func errorService(ctx *parseContext, name string, path string) *ComposeServiceConfig {
+	return &ComposeServiceConfig{
+		Name:      name,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Status:    "error",
+		Location:  ctx.locationAt(path),
+	}
+}
+
+// attachServiceDiagnostics добавляет сервисам относящиеся к ним диагностики
+// и помечает сервисы с ошибками статусом "error"
+func attachServiceDiagnostics(project *ComposeProjectConfig) {
+	for _, diagnostic := range project.Diagnostics {
+		name := diagnosticService(project, diagnostic.Path)
+		if name == "" {
+			continue
+		}
+		service := project.Services[name]
+		service.Diagnostics = append(service.Diagnostics, diagnostic)
+		if diagnostic.Severity == SeverityError {
+			service.Status = "error"
+		}
+	}
+}
+
+// diagnosticService возвращает имя сервиса, к элементу которого относится путь.
+// Имена сервисов могут содержать точки, поэтому выбирается самое длинное совпадение
+func diagnosticService(project *ComposeProjectConfig, path string) string {
+	if !strings.HasPrefix(path, "services.") {
+		return ""
+	}
+	rest := strings.TrimPrefix(path, "services.")
+
+	match := ""
+	for name := range project.Services {
+		if !strings.HasPrefix(rest, name) || len(name) <= len(match) {
+			continue
+		}
+		if len(rest) == len(name) || rest[len(name)] == '.' || rest[len(name)] == '[' {
+			match = name
+		}
+	}
+	return match
+}
@@ -0,0 +1,271 @@
+package compose_parser
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Форматы файлов переменных окружения
+const (
+	EnvFileFormatDotEnv = ""    // Формат .env с кавычками, escape-последовательностями и подстановкой переменных
+	EnvFileFormatRaw    = "raw" // Строки KEY=VALUE, значения используются как есть
+)
+
+// dotenvEscape описывает escape-последовательности, поддерживаемые в двойных кавычках
+var dotenvEscape = regexp.MustCompile(`\\(?:[abcfnrtv$"\\]|0\d{0,3})`)
+
+// ParseDotEnv разбирает содержимое файла переменных окружения в формате Compose:
+// KEY=VALUE или KEY: VALUE, необязательный префикс export и комментарии "#".
+// Значение без кавычек читается до конца строки без комментария " #", значение
+// в одинарных кавычках используется как есть, в двойных кавычках обрабатываются
+// escape-последовательности. Значения в кавычках могут занимать несколько строк.
+// В значениях без кавычек и в двойных кавычках подставляются переменные из lookup
+// и объявленные выше в файле, lookup имеет приоритет. Строка KEY без значения берет
+// значение из lookup, если оно задано. Переменные, имя которых начинается с цифры, пропускаются
+func ParseDotEnv(data []byte, lookup LookupFunc) (map[string]string, error) {
+	if lookup == nil {
+		lookup = MapLookup(nil)
+	}
+	parser := &dotenvParser{line: 1, values: make(map[string]string), lookup: lookup}
+	if err := parser.parse(string(bytes.TrimPrefix(data, []byte("\uFEFF")))); err != nil {
+		return nil, err
+	}
+
+	for key := range parser.values {
+		if unicode.IsDigit(rune(key[0])) {
+			delete(parser.values, key)
+		}
+	}
+	return parser.values, nil
+}
+
+// readEnvFile читает файл переменных окружения в формате .env
+func readEnvFile(filePath string, lookup LookupFunc) (map[string]string, error) {
+	return readEnvFileFormat(filePath, EnvFileFormatDotEnv, lookup)
+}
+
+// readEnvFileFormat читает файл переменных окружения в указанном формате
+func readEnvFileFormat(filePath string, format string, lookup LookupFunc) (map[string]string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]string
+	switch format {
+	case EnvFileFormatDotEnv:
+		values, err = ParseDotEnv(data, lookup)
+	case EnvFileFormatRaw:
+		values, err = parseRawEnv(data, lookup)
+	default:
+		return nil, fmt.Errorf("unsupported env file format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	return values, nil
+}
+
+// parseRawEnv разбирает строки KEY=VALUE без обработки кавычек и подстановки переменных
+func parseRawEnv(data []byte, lookup LookupFunc) (map[string]string, error) {
+	values := make(map[string]string)
+	for i, line := range strings.Split(string(bytes.TrimPrefix(data, []byte("\uFEFF"))), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", i+1, key)
+		}
+		if !ok {
+			if inherited, found := lookup(key); found {
+				values[key] = inherited
+			}
+			continue
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// dotenvParser хранит состояние разбора файла .env
+type dotenvParser struct {
+	line   int               // Номер текущей строки для сообщений об ошибках
+	values map[string]string // Разобранные переменные
+	lookup LookupFunc        // Внешний источник переменных для подстановки
+}
+
+// parse разбирает объявления переменных до конца данных
+func (p *dotenvParser) parse(src string) error {
+	for {
+		src = p.skipToStatement(src)
+		if src == "" {
+			return nil
+		}
+
+		key, rest, inherited, err := p.readKey(src)
+		if err != nil {
+			return err
+		}
+		if inherited {
+			if value, ok := p.lookup(key); ok {
+				p.values[key] = value
+			}
+			src = rest
+			continue
+		}
+
+		value, rest, err := p.readValue(rest)
+		if err != nil {
+			return err
+		}
+		p.values[key] = value
+		src = rest
+	}
+}
+
+// skipToStatement пропускает пробельные символы и строки комментариев
+func (p *dotenvParser) skipToStatement(src string) string {
+	for {
+		pos := strings.IndexFunc(src, func(r rune) bool {
+			if r == '\n' {
+				p.line++
+			}
+			return !unicode.IsSpace(r)
+		})
+		if pos == -1 {
+			return ""
+		}
+		src = src[pos:]
+		if src[0] != '#' {
+			return src
+		}
+
+		end := strings.IndexByte(src, '\n')
+		if end == -1 {
+			return ""
+		}
+		src = src[end:]
+	}
+}
+
+// readKey читает имя переменной и разделитель. inherited означает строку KEY без значения
+func (p *dotenvParser) readKey(src string) (key string, rest string, inherited bool, err error) {
+	if after, ok := strings.CutPrefix(src, "export"); ok && after != "" && isDotenvSpace(rune(after[0])) {
+		src = strings.TrimLeftFunc(after, isDotenvSpace)
+	}
+
+	// Последняя строка без разделителя и перевода строки наследует значение
+	key, rest, inherited = src, "", true
+	for i, r := range src {
+		if r == '=' || r == ':' || r == '\n' {
+			key, rest, inherited = src[:i], src[i+1:], r == '\n'
+			break
+		}
+		if isDotenvSpace(r) || r == '_' || r == '.' || r == '-' || r == '[' || r == ']' ||
+			unicode.IsLetter(r) || unicode.IsNumber(r) {
+			continue
+		}
+		firstLine, _, _ := strings.Cut(src, "\n")
+		return "", "", false, fmt.Errorf("line %d: unexpected character %q in variable name %q", p.line, string(r), firstLine)
+	}
+
+	key = strings.TrimRightFunc(key, isDotenvSpace)
+	if key == "" {
+		return "", "", false, fmt.Errorf("line %d: missing variable name", p.line)
+	}
+	if strings.ContainsFunc(key, isDotenvSpace) {
+		return "", "", false, fmt.Errorf("line %d: variable name %q cannot contain a space", p.line, key)
+	}
+	if inherited {
+		p.line++
+	}
+	return key, strings.TrimLeftFunc(rest, isDotenvSpace), inherited, nil
+}
+
+// readValue читает значение переменной и возвращает оставшиеся данные
+func (p *dotenvParser) readValue(src string) (string, string, error) {
+	if src == "" || (src[0] != '"' && src[0] != '\'') {
+		value, rest, _ := strings.Cut(src, "\n")
+		value, _, _ = strings.Cut(value, " #")
+		value, err := p.expand(strings.TrimRightFunc(value, unicode.IsSpace), p.line)
+		p.line++
+		return value, rest, err
+	}
+
+	// Ошибки значения в кавычках указывают на строку открывающей кавычки
+	quote, start := src[0], p.line
+	var value []byte
+	escaped := false
+	for i := 1; i < len(src); i++ {
+		c := src[i]
+		if c == '\n' {
+			p.line++
+		}
+		if c == quote && !escaped {
+			if quote == '\'' {
+				return string(value), src[i+1:], nil
+			}
+			expanded, err := p.expand(expandDotenvEscapes(string(value)), start)
+			return expanded, src[i+1:], err
+		}
+		if c == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		if escaped && c != quote {
+			value = append(value, '\\')
+		}
+		escaped = false
+		value = append(value, c)
+	}
+
+	firstLine, _, _ := strings.Cut(src, "\n")
+	return "", "", fmt.Errorf("line %d: unterminated quoted value %s", start, firstLine)
+}
+
+// expand подставляет переменные в значение: сначала из внешнего источника,
+// затем из объявленных выше в файле. line - строка значения для сообщения об ошибке
+func (p *dotenvParser) expand(value string, line int) (string, error) {
+	expanded, err := interpolate(value, chainLookup(p.lookup, MapLookup(p.values)), nil)
+	if err != nil {
+		return "", fmt.Errorf("line %d: %w", line, err)
+	}
+	return expanded, nil
+}
+
+// expandDotenvEscapes заменяет escape-последовательности значения в двойных кавычках.
+// \$ заменяется на $$, чтобы символ доллара не участвовал в подстановке
+func expandDotenvEscapes(value string) string {
+	return dotenvEscape.ReplaceAllStringFunc(value, func(match string) string {
+		if match == `\$` {
+			return "$$"
+		}
+		// Восьмеричная последовательность \0123 соответствует \123 в Go
+		if strings.HasPrefix(match, `\0`) && len(match) > 2 {
+			match = `\` + match[2:]
+		}
+		r, _, _, err := strconv.UnquoteChar(match, '"')
+		if err != nil {
+			return match
+		}
+		return string(r)
+	})
+}
+
+// isDotenvSpace проверяет, является ли символ пробельным, кроме перевода строки
+func isDotenvSpace(r rune) bool {
+	switch r {
+	case '\t', '\v', '\f', '\r', ' ', 0x85, 0xA0:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package compose_parser
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	lookup := MapLookup(map[string]string{"HOME": "/home/user", "INHERITED": "from-env"})
+
+	tests := []struct {
+		name string
+		data string
+		want map[string]string
+	}{
+		{"plain", "A=1\nB=two words\n", map[string]string{"A": "1", "B": "two words"}},
+		{"colon separator", "A: 1\n", map[string]string{"A": "1"}},
+		{"export prefix", "export A=1\nexport\tB=2\n", map[string]string{"A": "1", "B": "2"}},
+		{"spaces around separator", "A = 1 \n", map[string]string{"A": "1"}},
+		{"empty value", "A=\nB=\"\"\n", map[string]string{"A": "", "B": ""}},
+		{"comments", "# comment\nA=1 # trailing\nB=x#y\n  # indented\n", map[string]string{"A": "1", "B": "x#y"}},
+		{"single quotes", `A='$HOME \n # raw'`, map[string]string{"A": `$HOME \n # raw`}},
+		{"double quotes", `A="a # b"`, map[string]string{"A": "a # b"}},
+		{"double quote escapes", `A="line\nnext\ttab \"q\" \\ \$HOME"`, map[string]string{"A": "line\nnext\ttab \"q\" \\ $HOME"}},
+		{"unknown escape kept", `A="\d"`, map[string]string{"A": `\d`}},
+		{"multiline double quotes", "A=\"first\nsecond\"\nB=2\n", map[string]string{"A": "first\nsecond", "B": "2"}},
+		{"multiline single quotes", "A='first\nsecond'\n", map[string]string{"A": "first\nsecond"}},
+		{"crlf", "A=1\r\nB=2\r\n", map[string]string{"A": "1", "B": "2"}},
+		{"byte order mark", "\uFEFFA=1\n", map[string]string{"A": "1"}},
+		{"lookup interpolation", "A=${HOME}/app\nB=\"$HOME\"\n", map[string]string{"A": "/home/user/app", "B": "/home/user"}},
+		{"earlier entries", "A=1\nB=${A}2\nC=${MISSING:-${B}}\n", map[string]string{"A": "1", "B": "12", "C": "12"}},
+		{"lookup has priority", "HOME=/tmp\nA=$HOME\n", map[string]string{"HOME": "/tmp", "A": "/home/user"}},
+		{"inherited", "INHERITED\nUNKNOWN\nA=1", map[string]string{"INHERITED": "from-env", "A": "1"}},
+		{"inherited last line", "A=1\nINHERITED", map[string]string{"A": "1", "INHERITED": "from-env"}},
+		{"dotted and bracketed names", "a.b=1\nc[0]=2\nd-e=3\n", map[string]string{"a.b": "1", "c[0]": "2", "d-e": "3"}},
+		{"digit names skipped", "1A=1\nB=2\n", map[string]string{"B": "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDotEnv([]byte(tt.data), lookup)
+			if err != nil {
+				t.Fatalf("ParseDotEnv(%q): %v", tt.data, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDotEnv(%q) = %q, want %q", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDotEnvErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"unterminated quote", "A=1\nB=\"open\n", `line 2: unterminated quoted value "open`},
+		{"space in name", "MY VAR=1\n", `variable name "MY VAR" cannot contain a space`},
+		{"invalid character", "A=1\nB$=2\n", `line 2: unexpected character "$"`},
+		{"missing name", "=1\n", "missing variable name"},
+		{"required variable", "A=${MISSING:?must be set}\n", "line 1: required variable MISSING is missing a value: must be set"},
+		{"required variable in multiline value", "A=1\nB=\"first\n${MISSING:?must be set}\"\n", "line 2: required variable MISSING"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDotEnv([]byte(tt.data), nil)
+			if err == nil {
+				t.Fatalf("ParseDotEnv(%q): expected an error", tt.data)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseDotEnv(%q) error = %q, want it to contain %q", tt.data, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadEnvFileFormat(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "app.env", "# comment\nA='quoted'\nB=$HOME # not a comment\nINHERITED\n")
+	lookup := MapLookup(map[string]string{"HOME": "/home/user", "INHERITED": "from-env"})
+
+	tests := []struct {
+		format  string
+		want    map[string]string
+		wantErr string
+	}{
+		{EnvFileFormatDotEnv, map[string]string{"A": "quoted", "B": "/home/user", "INHERITED": "from-env"}, ""},
+		{EnvFileFormatRaw, map[string]string{"A": "'quoted'", "B": "$HOME # not a comment", "INHERITED": "from-env"}, ""},
+		{"json", nil, `unsupported env file format "json"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			got, err := readEnvFileFormat(path, tt.format, lookup)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readEnvFileFormat(%q): %v", tt.format, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readEnvFileFormat(%q) = %q, want %q", tt.format, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := readEnvFile(filepath.Join(dir, "missing.env"), lookup); err == nil {
+		t.Error("readEnvFile of a missing file: expected an error")
+	}
+}
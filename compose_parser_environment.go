@@ -0,0 +1,208 @@
+package compose_parser
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Имя файла переменных проекта в директории проекта
+const projectEnvFile = ".env"
+
+// loadProjectEnv читает файл .env из директории проекта. Отсутствие файла не является ошибкой
+func loadProjectEnv(projectDir string, lookup LookupFunc) (map[string]string, error) {
+	envFile := filepath.Join(projectDir, projectEnvFile)
+	if info, err := os.Stat(envFile); err != nil || info.IsDir() {
+		return nil, nil
+	}
+
+	values, err := readEnvFile(envFile, lookup)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env file %s: %w", envFile, err)
+	}
+	return values, nil
+}
+
+// parseEnvFiles парсит env_file сервиса в короткой (путь или список путей) или длинной
+// (path, required, format) форме и читает файлы. Относительные пути разрешаются от директории
+// проекта, пути из файлов переопределения, include и extends пересчитываются в нее при загрузке.
+// Возвращает файлы и переменные из них: значения из следующих файлов заменяют предыдущие
+func (p *ComposeParser) parseEnvFiles(ctx *parseContext, node *yaml.Node, path string) ([]EnvFileConfig, map[string]string, error) {
+	if isNullNode(node) {
+		return nil, nil, nil
+	}
+
+	items := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		items = node.Content
+	}
+
+	var envFiles []EnvFileConfig
+	values := make(map[string]string)
+	for i, item := range items {
+		itemPath := path
+		if node.Kind == yaml.SequenceNode {
+			itemPath = indexPath(path, i)
+		}
+
+		envFile, err := p.parseEnvFile(item, itemPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		envFile.Path = absolutePath(ctx.workingDir, envFile.Path)
+		envFiles = append(envFiles, envFile)
+
+		// Значения из предыдущих файлов доступны для подстановки и имеют приоритет над окружением
+		fileValues, err := readEnvFileFormat(envFile.Path, envFile.Format, chainLookup(MapLookup(values), ctx.lookup))
+		if errors.Is(err, fs.ErrNotExist) {
+			if !envFile.Required {
+				continue
+			}
+			return nil, nil, newReferenceError(ErrCodeUndefinedReference, item, itemPath, "file", envFile.Path,
+				"env file %s not found", envFile.Path)
+		}
+		if err != nil {
+			return nil, nil, invalidValueError(item, itemPath, "failed to read env file: %v", err)
+		}
+		for key, value := range fileValues {
+			values[key] = value
+		}
+	}
+
+	return envFiles, values, nil
+}
+
+// parseEnvFile парсит один элемент env_file
+func (p *ComposeParser) parseEnvFile(node *yaml.Node, path string) (EnvFileConfig, error) {
+	envFile := EnvFileConfig{Required: true}
+	if node.Kind != yaml.MappingNode {
+		value, err := decodeString(node, path)
+		envFile.Path = value
+		if err == nil && value == "" {
+			err = invalidValueError(node, path, "env file path must not be empty")
+		}
+		return envFile, err
+	}
+
+	d := p.newNodeDecoder(node, path)
+	envFile.Path = d.stringField("path")
+	if d.has("required") {
+		envFile.Required = d.boolField("required")
+	}
+	envFile.Format = d.stringField("format")
+	if err := d.Err(); err != nil {
+		return envFile, err
+	}
+
+	if envFile.Path == "" {
+		return envFile, requiredFieldError(node, path, "path")
+	}
+	if envFile.Format != EnvFileFormatDotEnv && envFile.Format != EnvFileFormatRaw {
+		return envFile, invalidValueError(d.value("format"), d.fieldPath("format"),
+			"unsupported env file format %q, must be raw or omitted", envFile.Format)
+	}
+	return envFile, nil
+}
+
+// parseEnvironment парсит переменные environment в порядке объявления: отображение
+// или список "KEY=VALUE". Переменная без значения (KEY в списке, KEY: или KEY: null
+// в отображении) берется из окружения, KEY= и KEY: "" задают пустое значение.
+// Значение, пустое после подстановки (KEY: ${EMPTY}), остается строкой и тоже задает пустое значение
+func (p *ComposeParser) parseEnvironment(ctx *parseContext, node *yaml.Node, path string) ([]EnvironmentVariable, error) {
+	variables := make([]EnvironmentVariable, 0)
+	if isNullNode(node) {
+		return variables, nil
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		err := forEachEntry(node, path, func(name string, valueNode *yaml.Node, path string) error {
+			variable := EnvironmentVariable{Name: name, Location: ctx.locationAt(path)}
+			if !isNullNode(valueNode) {
+				value, err := decodeString(valueNode, path)
+				if err != nil {
+					return err
+				}
+				variable.Value = &value
+			}
+			variables = append(variables, variable)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	case yaml.SequenceNode:
+		err := forEachItem(node, path, func(item *yaml.Node, path string) error {
+			entry, err := decodeString(item, path)
+			if err != nil {
+				return err
+			}
+			name, value, ok := strings.Cut(entry, "=")
+			if name == "" {
+				return invalidValueError(item, path, "invalid environment variable %q, expected KEY=VALUE or KEY", entry)
+			}
+			variable := EnvironmentVariable{Name: name, Location: ctx.locationAt(path)}
+			if ok {
+				variable.Value = &value
+			}
+			variables = append(variables, variable)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, newTypeError(node, path, "a mapping or a list of KEY=VALUE strings")
+	}
+
+	return variables, nil
+}
+
+// resolveEnvironment возвращает значения переменных environment. Переменные без значения
+// берутся из источника переменных проекта (окружение или WithLookup/WithEnvironment и .env),
+// незаданные в нем пропускаются. С WithoutInterpolation переменные без значения не разрешаются
+func (p *ComposeParser) resolveEnvironment(ctx *parseContext, variables []EnvironmentVariable) map[string]string {
+	if variables == nil {
+		return nil
+	}
+
+	environment := make(map[string]string, len(variables))
+	for _, variable := range variables {
+		if variable.Value != nil {
+			environment[variable.Name] = *variable.Value
+			continue
+		}
+		if p.disableInterpolate {
+			delete(environment, variable.Name)
+			continue
+		}
+		if value, ok := ctx.lookup(variable.Name); ok {
+			environment[variable.Name] = value
+		} else {
+			delete(environment, variable.Name)
+		}
+	}
+	return environment
+}
+
+// mergeEnvironment объединяет переменные из env_file с переменными environment.
+// По правилам Compose значения environment имеют приоритет
+func mergeEnvironment(fileValues map[string]string, environment map[string]string) map[string]string {
+	if len(fileValues) == 0 {
+		return environment
+	}
+
+	merged := make(map[string]string, len(fileValues)+len(environment))
+	for key, value := range fileValues {
+		merged[key] = value
+	}
+	for key, value := range environment {
+		merged[key] = value
+	}
+	return merged
+}
@@ -0,0 +1,250 @@
+package compose_parser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Коды ошибок парсера. Значения кодов не меняются между версиями
+const (
+	ErrCodeSyntax              = "syntax_error"           // Некорректный YAML документ
+	ErrCodeType                = "type_mismatch"          // Значение имеет неверный тип
+	ErrCodeUnknownField        = "unknown_field"          // Поле не описано в спецификации Compose
+	ErrCodeUndefinedReference  = "undefined_reference"    // Ссылка на необъявленный сервис, сеть, том и т.д.
+	ErrCodeCircularReference   = "circular_reference"     // Циклическая ссылка в extends или include
+	ErrCodeInvalidValue        = "invalid_value"          // Значение не соответствует формату поля
+	ErrCodeRequiredField       = "required_field"         // Не задано обязательное поле
+	ErrCodeInterpolation       = "interpolation_error"    // Ошибка подстановки переменной
+	ErrCodeConflictingResource = "conflicting_definition" // Ресурс объявлен повторно с другим содержимым
+)
+
+// ComposeError реализуется всеми ошибками, привязанными к элементу документа.
+// Позволяет получить общие сведения об ошибке через errors.As независимо от ее типа
+type ComposeError interface {
+	error
+	Details() *ErrorDetails
+}
+
+// ErrorDetails содержит общие сведения об ошибке парсера
+type ErrorDetails struct {
+	Code     string          // Стабильный код ошибки, см. ErrCode*
+	Path     string          // Путь к элементу, например services.web.ports[2]
+	Location *SourceLocation // Положение элемента в исходном файле, если известно
+	Value    string          // Значение, вызвавшее ошибку
+	Message  string          // Описание ошибки
+
+	node *yaml.Node // Узел элемента для определения файла
+}
+
+// Details возвращает общие сведения об ошибке
+func (d *ErrorDetails) Details() *ErrorDetails {
+	return d
+}
+
+// Error возвращает описание ошибки с путем и положением элемента
+func (d *ErrorDetails) Error() string {
+	message := d.Message
+	if d.Path != "" {
+		message = d.Path + ": " + message
+	}
+	if d.Location != nil {
+		message += " (" + d.Location.String() + ")"
+	}
+	return message
+}
+
+// SyntaxError описывает ошибку разбора YAML документа
+type SyntaxError struct {
+	ErrorDetails
+	Err error // Исходная ошибка YAML парсера
+}
+
+// Unwrap возвращает исходную ошибку YAML парсера
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// TypeError описывает значение неверного типа
+type TypeError struct {
+	ErrorDetails
+	Expected string // Ожидаемый тип, например "an integer"
+}
+
+// UnknownFieldError описывает поле, не описанное в спецификации Compose
+type UnknownFieldError struct {
+	ErrorDetails
+	Field      string // Имя неизвестного поля
+	Suggestion string // Наиболее похожее известное поле, если найдено
+}
+
+// ReferenceError описывает ссылку на необъявленный или циклически зависимый элемент
+type ReferenceError struct {
+	ErrorDetails
+	Kind string // Вид элемента: service, network, volume, secret, config, file
+	Name string // Имя элемента, на который указывает ссылка
+}
+
+// ValidationError описывает значение, не соответствующее правилам Compose
+type ValidationError struct {
+	ErrorDetails
+	Err error // Исходная ошибка, если есть
+}
+
+// Unwrap возвращает исходную ошибку
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// yamlErrorLine извлекает номер строки из сообщения YAML парсера
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// newSyntaxError создает ошибку разбора YAML документа
+func newSyntaxError(err error, message string) *SyntaxError {
+	syntaxErr := &SyntaxError{
+		ErrorDetails: ErrorDetails{Code: ErrCodeSyntax, Message: message},
+		Err:          err,
+	}
+	if err != nil {
+		syntaxErr.Message = fmt.Sprintf("%s: %v", message, err)
+		if match := yamlErrorLine.FindStringSubmatch(err.Error()); match != nil {
+			line, _ := strconv.Atoi(match[1])
+			syntaxErr.Location = &SourceLocation{Line: line}
+		}
+	}
+	return syntaxErr
+}
+
+// newTypeError создает ошибку несоответствия типа значения
+func newTypeError(node *yaml.Node, path string, expected string) *TypeError {
+	return &TypeError{
+		ErrorDetails: ErrorDetails{
+			Code:     ErrCodeType,
+			Path:     path,
+			Location: nodeLocation(node),
+			Value:    nodeValue(node),
+			Message:  fmt.Sprintf("must be %s, got %s", expected, describeNode(node)),
+			node:     node,
+		},
+		Expected: expected,
+	}
+}
+
+// newValidationError создает ошибку значения с указанным кодом
+func newValidationError(code string, node *yaml.Node, path string, format string, args ...interface{}) *ValidationError {
+	validationErr := &ValidationError{
+		ErrorDetails: ErrorDetails{
+			Code:     code,
+			Path:     path,
+			Location: nodeLocation(node),
+			Value:    nodeValue(node),
+			Message:  fmt.Sprintf(format, args...),
+			node:     node,
+		},
+	}
+	for _, arg := range args {
+		if err, ok := arg.(error); ok {
+			validationErr.Err = err
+		}
+	}
+	return validationErr
+}
+
+// invalidValueError создает ошибку значения, не соответствующего формату поля
+func invalidValueError(node *yaml.Node, path string, format string, args ...interface{}) *ValidationError {
+	return newValidationError(ErrCodeInvalidValue, node, path, format, args...)
+}
+
+// requiredFieldError создает ошибку отсутствующего обязательного поля.
+// node - узел отображения, в котором должно быть задано поле
+func requiredFieldError(node *yaml.Node, path string, field string) *ValidationError {
+	validationErr := newValidationError(ErrCodeRequiredField, node, joinPath(path, field), "%s is required", field)
+	validationErr.Value = ""
+	return validationErr
+}
+
+// newUnknownFieldError создает ошибку поля, не описанного в спецификации Compose.
+// node - узел ключа поля, path - путь к отображению, в котором оно задано
+func newUnknownFieldError(node *yaml.Node, path string, field string, suggestion string) *UnknownFieldError {
+	message := fmt.Sprintf("unknown field %q", field)
+	if suggestion != "" {
+		message += fmt.Sprintf(", did you mean %q?", suggestion)
+	}
+	return &UnknownFieldError{
+		ErrorDetails: ErrorDetails{
+			Code:     ErrCodeUnknownField,
+			Path:     joinPath(path, field),
+			Location: nodeLocation(node),
+			Value:    field,
+			Message:  message,
+			node:     node,
+		},
+		Field:      field,
+		Suggestion: suggestion,
+	}
+}
+
+// newReferenceError создает ошибку ссылки на необъявленный элемент
+func newReferenceError(code string, node *yaml.Node, path string, kind string, name string, format string, args ...interface{}) *ReferenceError {
+	return &ReferenceError{
+		ErrorDetails: ErrorDetails{
+			Code:     code,
+			Path:     path,
+			Location: nodeLocation(node),
+			Value:    name,
+			Message:  fmt.Sprintf(format, args...),
+			node:     node,
+		},
+		Kind: kind,
+		Name: name,
+	}
+}
+
+// nodeLocation возвращает положение узла без имени файла, оно дополняется
+// контекстом парсинга через completeError
+func nodeLocation(node *yaml.Node) *SourceLocation {
+	if node == nil || node.Line == 0 {
+		return nil
+	}
+	endLine, endColumn := nodeEnd(node)
+	return &SourceLocation{Line: node.Line, Column: node.Column, EndLine: endLine, EndColumn: endColumn}
+}
+
+// nodeValue возвращает значение скалярного узла для сообщений об ошибках
+func nodeValue(node *yaml.Node) string {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return node.Value
+}
+
+// completeError дополняет положение ошибки файлом, из которого загружен элемент.
+// file используется, если файл узла неизвестен. Если узел ошибки неизвестен,
+// положение определяется по пути элемента
+func (ctx *parseContext) completeError(err error, file string) error {
+	var composeErr ComposeError
+	if !errors.As(err, &composeErr) {
+		return err
+	}
+
+	details := composeErr.Details()
+	if details.Location == nil {
+		if location, ok := ctx.locations[details.Path]; ok && details.Path != "" {
+			details.Location = &location
+		}
+		return err
+	}
+	if details.Location.File == "" {
+		details.Location.File = ctx.sourceFile(details.node)
+	}
+	if details.Location.File == "" {
+		details.Location.File = file
+	}
+	if details.node != nil {
+		details.Location.EndLine, details.Location.EndColumn = ctx.nodeEnd(details.node)
+	}
+	return err
+}
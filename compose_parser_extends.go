@@ -0,0 +1,288 @@
+package compose_parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// inheritedField описывает поле сервиса, полученное от базового сервиса через extends
+type inheritedField struct {
+	origin string     // Базовый сервис в формате "service" или "file:service"
+	node   *yaml.Node // Узел значения на момент наследования
+}
+
+// extendsResolver разрешает extends сервисов одного документа
+type extendsResolver struct {
+	parser   *ComposeParser
+	ctx      *parseContext
+	files    map[string]*yaml.Node // Загруженные базовые файлы по абсолютному пути
+	resolved map[string]*yaml.Node // Разрешенные сервисы по ключу "файл#сервис"
+	stack    []string              // Цепочка разрешаемых сервисов для обнаружения циклов
+}
+
+// resolveExtends заменяет в документе все сервисы с extends на результат слияния
+// с базовыми сервисами. filePath - путь к файлу документа или "" для данных без файла
+func (p *ComposeParser) resolveExtends(ctx *parseContext, rootNode *yaml.Node, filePath string) error {
+	servicesNode := mappingValue(rootNode, "services")
+	if servicesNode == nil || servicesNode.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	if filePath != "" {
+		absPath, err := filepath.Abs(filePath)
+		if err != nil {
+			return err
+		}
+		filePath = absPath
+	}
+
+	resolver := &extendsResolver{
+		parser:   p,
+		ctx:      ctx,
+		files:    make(map[string]*yaml.Node),
+		resolved: make(map[string]*yaml.Node),
+	}
+
+	for i := 0; i+1 < len(servicesNode.Content); i += 2 {
+		name := servicesNode.Content[i].Value
+		if _, err := resolver.resolveService(filePath, rootNode, name); err != nil {
+			if err := p.tolerate(ctx, err, filePath); err != nil {
+				return err
+			}
+			// В режиме WithErrorTolerance сервис с ошибкой остается без слияния
+			resolver.resolved[filePath+"#"+name] = servicesNode.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// resolveService возвращает узел сервиса с примененным extends
+func (r *extendsResolver) resolveService(filePath string, rootNode *yaml.Node, name string) (*yaml.Node, error) {
+	key := filePath + "#" + name
+	if resolved, ok := r.resolved[key]; ok {
+		return resolved, nil
+	}
+
+	servicesNode := mappingValue(rootNode, "services")
+	serviceNode := mappingValue(servicesNode, name)
+
+	extendsNode := mappingValue(serviceNode, "extends")
+	if extendsNode == nil || serviceNode.Kind != yaml.MappingNode {
+		r.resolved[key] = serviceNode
+		return serviceNode, nil
+	}
+
+	extendsPath := "services." + name + ".extends"
+	for _, item := range r.stack {
+		if item == key {
+			return nil, newReferenceError(ErrCodeCircularReference, extendsNode, extendsPath, "service", name,
+				"circular reference with extends: %s -> %s", strings.Join(r.stack, " -> "), key)
+		}
+	}
+	r.stack = append(r.stack, key)
+	defer func() { r.stack = r.stack[:len(r.stack)-1] }()
+
+	baseName, baseFile, err := extendsTarget(extendsNode, extendsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Определяем документ, в котором объявлен базовый сервис
+	baseRoot, basePath := rootNode, filePath
+	if baseFile != "" {
+		basePath = baseFile
+		if !filepath.IsAbs(basePath) {
+			basePath = filepath.Join(filepath.Dir(filePath), basePath)
+		}
+		if basePath, err = filepath.Abs(basePath); err != nil {
+			return nil, err
+		}
+		if baseRoot, err = r.loadBaseFile(basePath); err != nil {
+			return nil, fmt.Errorf("failed to extend services %s: %w", name, err)
+		}
+	}
+
+	if mappingValue(mappingValue(baseRoot, "services"), baseName) == nil {
+		if baseFile == "" {
+			return nil, newReferenceError(ErrCodeUndefinedReference, extendsNode, extendsPath, "service", baseName,
+				"cannot extend service %q: service not found", baseName)
+		}
+		return nil, newReferenceError(ErrCodeUndefinedReference, extendsNode, extendsPath, "service", baseName,
+			"cannot extend service %q: service not found in %s", baseName, basePath)
+	}
+
+	baseNode, err := r.resolveService(basePath, baseRoot, baseName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Сливаем копию базового сервиса с дочерним, extends базового не наследуется
+	merged := r.ctx.copyNode(baseNode)
+	deleteMappingValue(merged, "extends")
+	if basePath != filePath {
+		rebaseServicePaths(merged, filepath.Dir(basePath), filepath.Dir(filePath))
+	}
+
+	origin := baseName
+	if baseFile != "" {
+		origin = baseFile + ":" + baseName
+	}
+	inherited := make(map[string]inheritedField)
+	for i := 0; i+1 < len(merged.Content); i += 2 {
+		field := merged.Content[i].Value
+		if mappingValue(serviceNode, field) != nil {
+			continue
+		}
+		fieldOrigin := origin
+		if parent, ok := r.ctx.inherited[baseNode][field]; ok {
+			fieldOrigin = parent.origin
+			if baseFile != "" && !strings.Contains(fieldOrigin, ":") {
+				fieldOrigin = baseFile + ":" + fieldOrigin
+			}
+		}
+		inherited[field] = inheritedField{origin: fieldOrigin, node: merged.Content[i+1]}
+	}
+
+	merged = r.parser.mergeNodes(merged, r.ctx.copyNode(serviceNode), []string{"services", name})
+	r.ctx.inherited[merged] = inherited
+
+	setMappingValue(servicesNode, name, merged)
+	r.resolved[key] = merged
+	return merged, nil
+}
+
+// loadBaseFile загружает файл с базовым сервисом
+func (r *extendsResolver) loadBaseFile(filePath string) (*yaml.Node, error) {
+	if rootNode, ok := r.files[filePath]; ok {
+		return rootNode, nil
+	}
+	rootNode, err := r.parser.readFile(r.ctx, filePath, r.ctx.lookup)
+	if err != nil {
+		return nil, err
+	}
+	r.files[filePath] = rootNode
+	return rootNode, nil
+}
+
+// extendsTarget возвращает имя базового сервиса и файл из узла extends
+func extendsTarget(node *yaml.Node, path string) (string, string, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Value, "", nil
+	case yaml.MappingNode:
+		service := mappingScalar(node, "service")
+		if service == "" {
+			return "", "", requiredFieldError(node, path, "service")
+		}
+		return service, mappingScalar(node, "file"), nil
+	default:
+		return "", "", newTypeError(node, path, "a string or a mapping")
+	}
+}
+
+// rebaseServicePaths пересчитывает относительные пути сервиса, объявленного в другой директории
+func rebaseServicePaths(serviceNode *yaml.Node, fromDir, toDir string) {
+	rebase := func(node *yaml.Node) {
+		if node != nil && node.Kind == yaml.ScalarNode && isLocalPath(node.Value) {
+			node.Value = rebasePath(node.Value, fromDir, toDir)
+		}
+	}
+
+	if buildNode := mappingValue(serviceNode, "build"); buildNode != nil {
+		if buildNode.Kind == yaml.ScalarNode {
+			rebase(buildNode)
+		} else {
+			rebase(mappingValue(buildNode, "context"))
+		}
+	}
+
+	rebaseEnvFilePaths(serviceNode, fromDir, toDir)
+
+	if volumesNode := mappingValue(serviceNode, "volumes"); volumesNode != nil {
+		for _, item := range volumesNode.Content {
+			if item.Kind == yaml.MappingNode {
+				if mappingScalar(item, "type") == "bind" {
+					rebase(mappingValue(item, "source"))
+				}
+				continue
+			}
+			// Короткая форма разбирается так же, как при парсинге, чтобы не разделять букву диска Windows
+			if parts := splitVolumeSpec(item.Value); len(parts) >= 2 && isRelativePath(parts[0]) {
+				item.Value = rebasePath(parts[0], fromDir, toDir) + ":" + strings.Join(parts[1:], ":")
+			}
+		}
+	}
+}
+
+// rebaseEnvFilePaths пересчитывает относительные пути env_file сервиса в короткой и длинной форме
+func rebaseEnvFilePaths(serviceNode *yaml.Node, fromDir, toDir string) {
+	envFileNode := mappingValue(serviceNode, "env_file")
+	if envFileNode == nil {
+		return
+	}
+
+	items := []*yaml.Node{envFileNode}
+	if envFileNode.Kind == yaml.SequenceNode {
+		items = envFileNode.Content
+	}
+	for _, item := range items {
+		if item.Kind == yaml.MappingNode {
+			item = mappingValue(item, "path")
+		}
+		if item != nil && item.Kind == yaml.ScalarNode && isLocalPath(item.Value) {
+			item.Value = rebasePath(item.Value, fromDir, toDir)
+		}
+	}
+}
+
+// rebasePath пересчитывает относительный путь из директории fromDir в директорию toDir
+func rebasePath(path, fromDir, toDir string) string {
+	absPath := filepath.Join(fromDir, path)
+	absTo, err := filepath.Abs(toDir)
+	if err != nil {
+		return absPath
+	}
+	rel, err := filepath.Rel(absTo, absPath)
+	if err != nil {
+		return absPath
+	}
+	if rel != "." && !strings.HasPrefix(rel, "..") {
+		rel = "." + string(os.PathSeparator) + rel
+	}
+	return filepath.ToSlash(rel)
+}
+
+// isRelativePath проверяет, является ли путь явно относительным путем файловой системы
+func isRelativePath(path string) bool {
+	return path == "." || path == ".." || strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../")
+}
+
+// isLocalPath проверяет, является ли значение относительным локальным путем, а не URL
+func isLocalPath(path string) bool {
+	if path == "" || filepath.IsAbs(path) || strings.HasPrefix(path, "~") {
+		return false
+	}
+	return !strings.Contains(path, "://") && !strings.HasPrefix(path, "git@")
+}
+
+// inheritedFields возвращает поля сервиса, значения которых получены от базового сервиса.
+// Поля, переопределенные последующими файлами, не учитываются
+func inheritedFields(ctx *parseContext, serviceNode *yaml.Node) map[string]string {
+	inherited, ok := ctx.inherited[serviceNode]
+	if !ok || len(inherited) == 0 {
+		return nil
+	}
+
+	fields := make(map[string]string)
+	for field, item := range inherited {
+		if mappingValue(serviceNode, field) == item.node {
+			fields[field] = item.origin
+		}
+	}
+	return fields
+}
@@ -0,0 +1,74 @@
+package compose_parser
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestRebaseServicePaths(t *testing.T) {
+	fromDir := filepath.FromSlash("/project/common")
+	toDir := filepath.FromSlash("/project")
+
+	tests := []struct {
+		name    string
+		service string
+		path    string
+		want    string
+	}{
+		{"build string", "build: ./app", "build", "./common/app"},
+		{"build context", "build:\n  context: ../shared", "build.context", "./shared"},
+		{"build URL", "build: https://github.com/org/repo.git", "build", "https://github.com/org/repo.git"},
+		{"env_file string", "env_file: ./app.env", "env_file", "./common/app.env"},
+		{"env_file list", "env_file:\n  - app.env", "env_file[0]", "./common/app.env"},
+		{"env_file long", "env_file:\n  - path: ./app.env\n    required: false", "env_file[0].path", "./common/app.env"},
+		{"relative bind", "volumes:\n  - ./data:/data:ro", "volumes[0]", "./common/data:/data:ro"},
+		{"named volume", "volumes:\n  - data:/data", "volumes[0]", "data:/data"},
+		{"absolute bind", "volumes:\n  - /srv/data:/data", "volumes[0]", "/srv/data:/data"},
+		{"windows bind", "volumes:\n  - C:\\data:/data", "volumes[0]", "C:\\data:/data"},
+		{"windows target", "volumes:\n  - ./data:C:\\data:ro", "volumes[0]", "./common/data:C:\\data:ro"},
+		{"long bind", "volumes:\n  - type: bind\n    source: ./data\n    target: /data", "volumes[0].source", "./common/data"},
+		{"long volume", "volumes:\n  - type: volume\n    source: data\n    target: /data", "volumes[0].source", "data"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var document yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.service), &document); err != nil {
+				t.Fatalf("invalid test document: %v", err)
+			}
+			service := document.Content[0]
+
+			rebaseServicePaths(service, fromDir, toDir)
+
+			node := nodeAtPath(t, service, tt.path)
+			if node.Value != tt.want {
+				t.Errorf("%s = %q, want %q", tt.path, node.Value, tt.want)
+			}
+		})
+	}
+}
+
+// nodeAtPath возвращает узел по пути вида "build.context" или "env_file[0].path"
+func nodeAtPath(t *testing.T, node *yaml.Node, path string) *yaml.Node {
+	t.Helper()
+	for _, part := range strings.Split(path, ".") {
+		name, index, hasIndex := strings.Cut(part, "[")
+		node = mappingValue(node, name)
+		if node != nil && hasIndex {
+			i, err := strconv.Atoi(strings.TrimSuffix(index, "]"))
+			if err != nil || node.Kind != yaml.SequenceNode || i >= len(node.Content) {
+				node = nil
+			} else {
+				node = node.Content[i]
+			}
+		}
+		if node == nil {
+			t.Fatalf("no node at %s", path)
+		}
+	}
+	return node
+}
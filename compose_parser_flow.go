@@ -2,6 +2,8 @@ package compose_parser
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"sort"
 	"time"
 )
@@ -38,12 +40,20 @@ func (p *ComposeParser) ParseToReactFlow(project *ComposeProjectConfig, options
 	// 10. Создание связей сервисов с томами
 	serviceToVolumeEdges := p.createServiceToVolumeEdges(project, serviceNodes, volumeUsage)
 
-	// 11. Расчет viewport
+	// 11. Создание нод секретов и конфигураций
+	fileNodes, fileNodeIDs := p.createSecretAndConfigNodes(project, options, dimensions)
+
+	// 12. Создание связей сервисов с секретами и конфигурациями
+	fileEdges := p.createServiceToSecretAndConfigEdges(project, fileNodeIDs)
+
+	// 13. Расчет viewport
 	allNodes := append(append(append([]ReactFlowNode{dockerComposeNode}, networkNodes...), serviceNodes...), volumeNodes...)
+	allNodes = append(allNodes, fileNodes...)
 	viewport := p.calculateViewport(allNodes, options)
 
-	// 12. Создание финального графа
+	// 14. Создание финального графа
 	allEdges := append(append(append(networkEdges, dependsEdges...), serviceToVolumeEdges...), append(edges, volumeEdges...)...)
+	allEdges = append(allEdges, fileEdges...)
 	return p.buildFinalGraph(project, allNodes, allEdges, viewport), nil
 }
 
@@ -136,12 +146,14 @@ func (p *ComposeParser) createDockerComposeNode(project *ComposeProjectConfig, o
 		Data: ReactFlowNodeData{
 			Label: project.Name,
 			Type:  "compose",
-			Properties: map[string]interface{}{
+			Properties: withExtensions(map[string]interface{}{
 				"services": dimensions.ServiceCount,
 				"networks": dimensions.NetworkCount,
 				"volumes":  dimensions.VolumeCount,
+				"secrets":  len(project.Secrets),
+				"configs":  len(project.Configs),
 				"version":  project.Version,
-			},
+			}, project.Extensions),
 		},
 	}
 }
@@ -175,15 +187,17 @@ func (p *ComposeParser) createNetworkNodes(project *ComposeProjectConfig, option
 				Y: float64(y),
 			},
 			Data: ReactFlowNodeData{
-				Label:   networkName,
-				Type:    "network",
-				Network: network,
-				Properties: map[string]interface{}{
+				Label:    networkName,
+				Type:     "network",
+				Network:  network,
+				Location: network.Location,
+				Properties: withExtensions(map[string]interface{}{
 					"driver":     network.Driver,
 					"internal":   network.Internal,
 					"external":   network.External,
 					"attachable": network.Attachable,
-				},
+					"subnets":    networkSubnets(network),
+				}, network.Extensions),
 			},
 		}
 		nodes = append(nodes, networkNode)
@@ -220,6 +234,12 @@ func (p *ComposeParser) createServiceNodes(project *ComposeProjectConfig, option
 		serviceMap[serviceName] = nodeID
 
 		nodeColor := "#3b82f6"
+		nodeStatus := "saved"
+		if service.Status == "error" {
+			// Сервис разобран частично, см. Diagnostics
+			nodeColor = "#ef4444"
+			nodeStatus = "error"
+		}
 
 		serviceNode := ReactFlowNode{
 			ID:   nodeID,
@@ -229,19 +249,22 @@ func (p *ComposeParser) createServiceNodes(project *ComposeProjectConfig, option
 				Y: float64(y),
 			},
 			Data: ReactFlowNodeData{
-				Label:   serviceName,
-				Type:    "service",
-				Service: service,
-				Status:  "saved",
-				Properties: map[string]interface{}{
+				Label:       serviceName,
+				Type:        "service",
+				Service:     service,
+				Location:    service.Location,
+				Status:      nodeStatus,
+				Diagnostics: service.Diagnostics,
+				Properties: withExtensions(map[string]interface{}{
 					"image":      service.Image,
 					"ports":      len(service.Ports),
 					"volumes":    len(service.Volumes),
 					"depends_on": len(service.DependsOn),
 					"networks":   len(service.Networks),
+					"profiles":   service.Profiles,
 					"color":      nodeColor,
 					"order":      service.Order,
-				},
+				}, service.Extensions),
 			},
 		}
 		nodes = append(nodes, serviceNode)
@@ -264,7 +287,8 @@ func (p *ComposeParser) createNetworkToServiceEdges(project *ComposeProjectConfi
 		nodeID := fmt.Sprintf("services-%s", serviceName)
 
 		hasNetworkConnections := false
-		for _, networkName := range service.Networks {
+		for _, serviceNetwork := range service.Networks {
+			networkName := serviceNetwork.Name
 			if networkNodeID, exists := networkNodeMap[networkName]; exists {
 				edgeCounter++
 				edgeLabel := networkName
@@ -288,6 +312,7 @@ func (p *ComposeParser) createNetworkToServiceEdges(project *ComposeProjectConfi
 					Label:      edgeLabel,
 					LabelStyle: labelStyle,
 					Animated:   true,
+					Data:       p.serviceNetworkEdgeData(serviceNetwork),
 				}
 				edges = append(edges, edge)
 				hasNetworkConnections = true
@@ -315,6 +340,39 @@ func (p *ComposeParser) createNetworkToServiceEdges(project *ComposeProjectConfi
 	return edges
 }
 
+// serviceNetworkEdgeData возвращает алиасы и адреса подключения сервиса к сети для данных связи
+func (p *ComposeParser) serviceNetworkEdgeData(network ServiceNetworkConfig) map[string]interface{} {
+	data := make(map[string]interface{})
+	if len(network.Aliases) > 0 {
+		data["aliases"] = network.Aliases
+	}
+	if network.IPv4Address != "" {
+		data["ipv4_address"] = network.IPv4Address
+	}
+	if network.IPv6Address != "" {
+		data["ipv6_address"] = network.IPv6Address
+	}
+	if len(network.LinkLocalIPs) > 0 {
+		data["link_local_ips"] = network.LinkLocalIPs
+	}
+	if network.MacAddress != "" {
+		data["mac_address"] = network.MacAddress
+	}
+	if network.InterfaceName != "" {
+		data["interface_name"] = network.InterfaceName
+	}
+	if network.Priority != 0 {
+		data["priority"] = network.Priority
+	}
+	if network.GwPriority != 0 {
+		data["gw_priority"] = network.GwPriority
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return data
+}
+
 // collectVolumeUsage собирает информацию об использовании томов сервисами
 func (p *ComposeParser) collectVolumeUsage(project *ComposeProjectConfig, serviceNodes []ReactFlowNode) (map[string][]string, map[string]ReactFlowPosition) {
 	volumeUsage := make(map[string][]string)
@@ -335,7 +393,7 @@ func (p *ComposeParser) collectVolumeUsage(project *ComposeProjectConfig, servic
 		service := item.service
 
 		for _, volumeMount := range service.Volumes {
-			if volumeMount.Type == "volume" && volumeMount.Source != "" {
+			if volumeMount.IsNamed() {
 				volumeName := volumeMount.Source
 				volumeUsage[volumeName] = append(volumeUsage[volumeName], serviceName)
 			}
@@ -445,16 +503,17 @@ func (p *ComposeParser) createVolumeNodes(project *ComposeProjectConfig, options
 				Y: float64(y),
 			},
 			Data: ReactFlowNodeData{
-				Label:  vol.name,
-				Type:   "volume",
-				Volume: vol.volume,
-				Properties: map[string]interface{}{
+				Label:    vol.name,
+				Type:     "volume",
+				Volume:   vol.volume,
+				Location: vol.volume.Location,
+				Properties: withExtensions(map[string]interface{}{
 					"driver":   vol.volume.Driver,
 					"external": vol.volume.External,
 					"order":    vol.volume.Order,
 					"used_by":  vol.usedBy,
 					"used":     true,
-				},
+				}, vol.volume.Extensions),
 			},
 		}
 		nodes = append(nodes, volumeNode)
@@ -479,18 +538,19 @@ func (p *ComposeParser) createVolumeNodes(project *ComposeProjectConfig, options
 				"opacity": 0.5,
 			},
 			Data: ReactFlowNodeData{
-				Label:  vol.name,
-				Type:   "volume",
-				Volume: vol.volume,
-				Status: "unused",
-				Properties: map[string]interface{}{
+				Label:    vol.name,
+				Type:     "volume",
+				Volume:   vol.volume,
+				Location: vol.volume.Location,
+				Status:   "unused",
+				Properties: withExtensions(map[string]interface{}{
 					"driver":   vol.volume.Driver,
 					"external": vol.volume.External,
 					"order":    vol.volume.Order,
 					"used_by":  vol.usedBy,
 					"used":     false,
 					"status":   "unused",
-				},
+				}, vol.volume.Extensions),
 			},
 		}
 		nodes = append(nodes, volumeNode)
@@ -537,8 +597,9 @@ func (p *ComposeParser) createDependsOnEdges(project *ComposeProjectConfig, serv
 		sourceID := fmt.Sprintf("services-%s", serviceName)
 
 		for _, dependsOn := range item.service.DependsOn {
-			if targetID, exists := serviceMap[dependsOn]; exists {
+			if targetID, exists := serviceMap[dependsOn.Service]; exists {
 				edgeCounter++
+				label, style := p.dependsOnEdgeStyle(dependsOn)
 				edge := ReactFlowEdge{
 					ID:           fmt.Sprintf("edge-depends-%d", edgeCounter),
 					Source:       sourceID,
@@ -546,6 +607,17 @@ func (p *ComposeParser) createDependsOnEdges(project *ComposeProjectConfig, serv
 					Target:       targetID,
 					TargetHandle: fmt.Sprintf("%s-target-2", targetID),
 					// Type:         "smoothstep",
+					Label: label,
+					Style: style,
+					LabelStyle: map[string]interface{}{
+						"fill":     style["stroke"],
+						"fontSize": "10px",
+					},
+					Data: map[string]interface{}{
+						"condition": dependsOn.Condition,
+						"restart":   dependsOn.Restart,
+						"required":  dependsOn.Required,
+					},
 				}
 				edges = append(edges, edge)
 			}
@@ -555,6 +627,38 @@ func (p *ComposeParser) createDependsOnEdges(project *ComposeProjectConfig, serv
 	return edges
 }
 
+// dependsOnEdgeStyle возвращает подпись и стиль связи зависимости в зависимости от условия
+func (p *ComposeParser) dependsOnEdgeStyle(dependsOn ServiceDependency) (string, map[string]interface{}) {
+	var label string
+	style := map[string]interface{}{
+		"strokeWidth": 1.5,
+		"stroke":      "#6b7280",
+	}
+
+	switch dependsOn.Condition {
+	case DependencyConditionHealthy:
+		label = "healthy"
+		style["stroke"] = "#10b981"
+	case DependencyConditionCompleted:
+		label = "completed"
+		style["stroke"] = "#8b5cf6"
+		style["strokeDasharray"] = "6,3"
+	}
+
+	// Необязательные зависимости отображаются пунктиром
+	if !dependsOn.Required {
+		style["strokeDasharray"] = "2,4"
+		style["opacity"] = 0.6
+		if label == "" {
+			label = "optional"
+		} else {
+			label += ", optional"
+		}
+	}
+
+	return label, style
+}
+
 // createServiceToVolumeEdges создает связи сервисов с томами
 func (p *ComposeParser) createServiceToVolumeEdges(project *ComposeProjectConfig, serviceNodes []ReactFlowNode, volumeUsage map[string][]string) []ReactFlowEdge {
 	edges := make([]ReactFlowEdge, 0)
@@ -567,7 +671,7 @@ func (p *ComposeParser) createServiceToVolumeEdges(project *ComposeProjectConfig
 		sourceID := fmt.Sprintf("services-%s", serviceName)
 
 		for _, volumeMount := range item.service.Volumes {
-			if volumeMount.Type == "volume" && volumeMount.Source != "" {
+			if volumeMount.IsNamed() {
 				targetID := fmt.Sprintf("volume-%s", volumeMount.Source)
 
 				// Проверяем, существует ли такой том
@@ -607,6 +711,128 @@ func (p *ComposeParser) createServiceToVolumeEdges(project *ComposeProjectConfig
 	return edges
 }
 
+// createSecretAndConfigNodes создает ноды секретов и конфигураций (колонка справа от томов).
+// Возвращает ноды и набор их идентификаторов (secret-<имя>, config-<имя>)
+func (p *ComposeParser) createSecretAndConfigNodes(project *ComposeProjectConfig, options *GraphLayoutOptions, dimensions *GraphDimensions) ([]ReactFlowNode, map[string]bool) {
+	nodes := make([]ReactFlowNode, 0)
+	nodeIDs := make(map[string]bool)
+
+	secretUsage := make(map[string][]string)
+	configUsage := make(map[string][]string)
+	for _, item := range p.getSortedServices(project.Services, project.ServiceOrder) {
+		for _, secret := range item.service.Secrets {
+			secretUsage[secret.Source] = append(secretUsage[secret.Source], item.name)
+		}
+		for _, config := range item.service.Configs {
+			configUsage[config.Source] = append(configUsage[config.Source], item.name)
+		}
+	}
+
+	x := dimensions.ServiceStartX + 2*options.VolumeXOffset
+	index := 0
+	addNode := func(kind string, name string, data ReactFlowNodeData, usedBy []string, properties map[string]interface{}) {
+		nodeID := fmt.Sprintf("%s-%s", kind, name)
+		nodeIDs[nodeID] = true
+
+		properties["used_by"] = usedBy
+		properties["used"] = len(usedBy) > 0
+		node := ReactFlowNode{
+			ID:   nodeID,
+			Type: kind,
+			Position: ReactFlowPosition{
+				X: float64(x),
+				Y: float64(options.Padding + index*options.ColumnTopGap),
+			},
+			Data: data,
+		}
+		node.Data.Label = name
+		node.Data.Type = kind
+		node.Data.Properties = properties
+		if len(usedBy) == 0 {
+			node.Style = map[string]interface{}{
+				"opacity": 0.5,
+			}
+			node.Data.Status = "unused"
+		}
+		nodes = append(nodes, node)
+		index++
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(project.Secrets)) {
+		secret := project.Secrets[name]
+		addNode("secret", name, ReactFlowNodeData{Secret: secret, Location: secret.Location}, secretUsage[name],
+			withExtensions(map[string]interface{}{
+				"file":     secret.File,
+				"external": secret.External,
+			}, secret.Extensions))
+	}
+	for _, name := range slices.Sorted(maps.Keys(project.Configs)) {
+		config := project.Configs[name]
+		addNode("config", name, ReactFlowNodeData{Config: config, Location: config.Location}, configUsage[name],
+			withExtensions(map[string]interface{}{
+				"file":     config.File,
+				"external": config.External,
+			}, config.Extensions))
+	}
+
+	return nodes, nodeIDs
+}
+
+// createServiceToSecretAndConfigEdges создает связи сервисов с подключенными секретами и конфигурациями
+func (p *ComposeParser) createServiceToSecretAndConfigEdges(project *ComposeProjectConfig, nodeIDs map[string]bool) []ReactFlowEdge {
+	edges := make([]ReactFlowEdge, 0)
+
+	addEdges := func(serviceName string, kind string, references []ServiceFileReference, color string) {
+		for i, reference := range references {
+			targetID := fmt.Sprintf("%s-%s", kind, reference.Source)
+			if !nodeIDs[targetID] {
+				continue
+			}
+			edges = append(edges, ReactFlowEdge{
+				ID:          fmt.Sprintf("edge-services-%s-%s-%d", kind, serviceName, i),
+				Source:      fmt.Sprintf("services-%s", serviceName),
+				Target:      targetID,
+				Type:        "smoothstep",
+				ServiceName: serviceName,
+				Style: map[string]interface{}{
+					"strokeWidth":     1,
+					"stroke":          color,
+					"strokeDasharray": "4,2",
+				},
+				Data: p.fileReferenceEdgeData(reference),
+			})
+		}
+	}
+
+	for _, item := range p.getSortedServices(project.Services, project.ServiceOrder) {
+		addEdges(item.name, "secret", item.service.Secrets, "#f59e0b")
+		addEdges(item.name, "config", item.service.Configs, "#14b8a6")
+	}
+
+	return edges
+}
+
+// fileReferenceEdgeData возвращает параметры монтирования секрета или конфигурации для данных связи
+func (p *ComposeParser) fileReferenceEdgeData(reference ServiceFileReference) map[string]interface{} {
+	data := make(map[string]interface{})
+	if reference.Target != "" {
+		data["target"] = reference.Target
+	}
+	if reference.UID != "" {
+		data["uid"] = reference.UID
+	}
+	if reference.GID != "" {
+		data["gid"] = reference.GID
+	}
+	if reference.Mode != nil {
+		data["mode"] = fmt.Sprintf("%#o", *reference.Mode)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return data
+}
+
 // calculateViewport рассчитывает viewport для отображения графа
 func (p *ComposeParser) calculateViewport(nodes []ReactFlowNode, options *GraphLayoutOptions) ReactFlowViewport {
 	var minX, minY, maxX, maxY float64
@@ -764,3 +990,25 @@ func (p *ComposeParser) getSortedVolumes(volumes map[string]*VolumeConfig, volum
 
 	return volumesList
 }
+
+// networkSubnets возвращает подсети пулов адресов сети
+func networkSubnets(network *NetworkConfig) []string {
+	subnets := make([]string, 0)
+	if network.IPAM == nil {
+		return subnets
+	}
+	for _, pool := range network.IPAM.Config {
+		if pool.Subnet != "" {
+			subnets = append(subnets, pool.Subnet)
+		}
+	}
+	return subnets
+}
+
+// withExtensions добавляет поля расширений x-* в свойства ноды
+func withExtensions(properties map[string]interface{}, extensions map[string]interface{}) map[string]interface{} {
+	if len(extensions) > 0 {
+		properties["extensions"] = extensions
+	}
+	return properties
+}
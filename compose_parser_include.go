@@ -0,0 +1,237 @@
+package compose_parser
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// includeSections содержит разделы верхнего уровня, ресурсы которых подключаются через include
+var includeSections = []string{"services", "networks", "volumes", "secrets", "configs"}
+
+// includeConfig представляет один элемент include
+type includeConfig struct {
+	Paths            []string // Подключаемые файлы, сливаются как override
+	ProjectDirectory string   // Директория, относительно которой разрешаются пути подключаемых файлов
+	EnvFiles         []string // Файлы переменных для интерполяции подключаемых файлов
+
+	node *yaml.Node // Узел элемента include для сообщений об ошибках
+	path string     // Путь элемента, например include[0]
+}
+
+// resolveIncludes загружает файлы из элемента include и добавляет их ресурсы в документ.
+// filePath - путь к подключающему файлу или "" для данных без файла
+func (p *ComposeParser) resolveIncludes(ctx *parseContext, rootNode *yaml.Node, filePath string) error {
+	includeNode := mappingValue(rootNode, "include")
+	if includeNode == nil {
+		return nil
+	}
+	deleteMappingValue(rootNode, "include")
+
+	if includeNode.Kind != yaml.SequenceNode {
+		return p.tolerate(ctx, newTypeError(includeNode, "include", "a list"), "")
+	}
+
+	baseDir, err := filepath.Abs(filepath.Dir(filePath))
+	if err != nil {
+		return err
+	}
+
+	for i, item := range includeNode.Content {
+		include, err := p.parseInclude(item, indexPath("include", i), baseDir)
+		if err != nil {
+			if err := p.tolerate(ctx, err, ""); err != nil {
+				return err
+			}
+			continue
+		}
+
+		included, err := p.loadInclude(ctx, include)
+		if err != nil {
+			if err := p.tolerate(ctx, err, ""); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if include.ProjectDirectory != baseDir {
+			rebaseIncludedPaths(included, include.ProjectDirectory, baseDir)
+		}
+
+		if err := mergeIncludedResources(rootNode, included, include.Paths[0]); err != nil {
+			if err := p.tolerate(ctx, err, ""); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseInclude парсит элемент include в короткой или полной форме.
+// Относительные пути разрешаются от директории подключающего файла
+func (p *ComposeParser) parseInclude(node *yaml.Node, path string, baseDir string) (*includeConfig, error) {
+	include := &includeConfig{node: node, path: path}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		include.Paths = []string{node.Value}
+
+	case yaml.MappingNode:
+		d := p.newNodeDecoder(node, path)
+		include.Paths = d.stringListField("path")
+		include.ProjectDirectory = d.stringField("project_directory")
+		include.EnvFiles = d.stringListField("env_file")
+		if err := d.Err(); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, newTypeError(node, path, "a string or a mapping")
+	}
+
+	if len(include.Paths) == 0 {
+		return nil, requiredFieldError(node, path, "path")
+	}
+
+	for i, includePath := range include.Paths {
+		include.Paths[i] = absolutePath(baseDir, includePath)
+	}
+
+	if include.ProjectDirectory == "" {
+		include.ProjectDirectory = filepath.Dir(include.Paths[0])
+	} else {
+		include.ProjectDirectory = absolutePath(baseDir, include.ProjectDirectory)
+	}
+
+	if len(include.EnvFiles) == 0 {
+		// По умолчанию используется .env в директории подключаемого проекта, если он существует
+		defaultEnvFile := filepath.Join(include.ProjectDirectory, ".env")
+		if _, err := os.Stat(defaultEnvFile); err == nil {
+			include.EnvFiles = []string{defaultEnvFile}
+		}
+	} else {
+		for i, envFile := range include.EnvFiles {
+			include.EnvFiles[i] = absolutePath(baseDir, envFile)
+		}
+	}
+
+	return include, nil
+}
+
+// loadInclude загружает и сливает файлы одного элемента include с его собственными переменными
+func (p *ComposeParser) loadInclude(ctx *parseContext, include *includeConfig) (*yaml.Node, error) {
+	for _, path := range ctx.includeStack {
+		if path == include.Paths[0] {
+			return nil, newReferenceError(ErrCodeCircularReference, include.node, include.path, "file", path,
+				"circular include: %s -> %s", strings.Join(ctx.includeStack, " -> "), path)
+		}
+	}
+	ctx.includeStack = append(ctx.includeStack, include.Paths[0])
+	defer func() { ctx.includeStack = ctx.includeStack[:len(ctx.includeStack)-1] }()
+
+	env := make(map[string]string)
+	for _, envFile := range include.EnvFiles {
+		values, err := readEnvFile(envFile, chainLookup(ctx.lookup, MapLookup(env)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read env file %s: %w", envFile, err)
+		}
+		for key, value := range values {
+			env[key] = value
+		}
+	}
+	lookup := chainLookup(ctx.lookup, MapLookup(env))
+
+	var merged *yaml.Node
+	for _, path := range include.Paths {
+		rootNode, err := p.loadFileWithLookup(ctx, path, lookup)
+		if err != nil {
+			return nil, fmt.Errorf("failed to include %s: %w", path, err)
+		}
+		if merged == nil {
+			merged = rootNode
+			continue
+		}
+		merged = p.mergeNodes(merged, rootNode, nil)
+	}
+
+	return merged, nil
+}
+
+// mergeIncludedResources добавляет ресурсы подключенного файла в документ.
+// Повторное объявление ресурса с другим содержимым считается конфликтом
+func mergeIncludedResources(rootNode, included *yaml.Node, includedPath string) error {
+	for _, section := range includeSections {
+		includedSection := mappingValue(included, section)
+		if includedSection == nil || includedSection.Kind != yaml.MappingNode {
+			continue
+		}
+
+		rootSection := mappingValue(rootNode, section)
+		if rootSection == nil || rootSection.Kind != yaml.MappingNode {
+			rootSection = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(rootNode, section, rootSection)
+		}
+
+		for i := 0; i+1 < len(includedSection.Content); i += 2 {
+			name := includedSection.Content[i].Value
+			if existing := mappingValue(rootSection, name); existing != nil {
+				if nodesEqual(existing, includedSection.Content[i+1]) {
+					continue
+				}
+				return newValidationError(ErrCodeConflictingResource, includedSection.Content[i], section+"."+name,
+					"imported compose file %s defines conflicting %s %q", includedPath, strings.TrimSuffix(section, "s"), name)
+			}
+			rootSection.Content = append(rootSection.Content, includedSection.Content[i], includedSection.Content[i+1])
+		}
+	}
+
+	return nil
+}
+
+// rebaseIncludedPaths пересчитывает относительные пути подключенного проекта
+// из его директории в директорию подключающего файла
+func rebaseIncludedPaths(rootNode *yaml.Node, fromDir, toDir string) {
+	if servicesNode := mappingValue(rootNode, "services"); servicesNode != nil {
+		for i := 1; i < len(servicesNode.Content); i += 2 {
+			rebaseServicePaths(servicesNode.Content[i], fromDir, toDir)
+		}
+	}
+
+	for _, section := range []string{"secrets", "configs"} {
+		sectionNode := mappingValue(rootNode, section)
+		if sectionNode == nil {
+			continue
+		}
+		for i := 1; i < len(sectionNode.Content); i += 2 {
+			if fileNode := mappingValue(sectionNode.Content[i], "file"); fileNode != nil && isLocalPath(fileNode.Value) {
+				fileNode.Value = rebasePath(fileNode.Value, fromDir, toDir)
+			}
+		}
+	}
+}
+
+// absolutePath возвращает абсолютный путь, разрешая относительный путь от baseDir
+func absolutePath(baseDir, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(baseDir, path)
+}
+
+// nodesEqual сравнивает содержимое двух узлов YAML
+func nodesEqual(a, b *yaml.Node) bool {
+	left, err := yaml.Marshal(a)
+	if err != nil {
+		return false
+	}
+	right, err := yaml.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(left, right)
+}
@@ -0,0 +1,261 @@
+package compose_parser
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LookupFunc возвращает значение переменной и признак того, что она задана
+type LookupFunc func(name string) (string, bool)
+
+// EnvLookup возвращает источник переменных на основе окружения процесса
+func EnvLookup() LookupFunc {
+	return os.LookupEnv
+}
+
+// MapLookup возвращает источник переменных на основе map
+func MapLookup(values map[string]string) LookupFunc {
+	return func(name string) (string, bool) {
+		value, ok := values[name]
+		return value, ok
+	}
+}
+
+// chainLookup возвращает источник, опрашивающий переданные источники по порядку
+func chainLookup(lookups ...LookupFunc) LookupFunc {
+	return func(name string) (string, bool) {
+		for _, lookup := range lookups {
+			if lookup == nil {
+				continue
+			}
+			if value, ok := lookup(name); ok {
+				return value, true
+			}
+		}
+		return "", false
+	}
+}
+
+// Interpolate подставляет переменные в строку по правилам Compose:
+// $VAR, ${VAR}, ${VAR-default}, ${VAR:-default}, ${VAR+replacement},
+// ${VAR:+replacement}, ${VAR?error}, ${VAR:?error} и экранирование $$
+func Interpolate(template string, lookup LookupFunc) (string, error) {
+	return interpolate(template, lookup, nil)
+}
+
+// interpolate подставляет переменные в строку. unset вызывается для каждой
+// незаданной переменной без значения по умолчанию, если не равен nil
+func interpolate(template string, lookup LookupFunc, unset func(name string)) (string, error) {
+	if lookup == nil {
+		lookup = EnvLookup()
+	}
+	if !strings.Contains(template, "$") {
+		return template, nil
+	}
+
+	var result strings.Builder
+	for i := 0; i < len(template); {
+		c := template[i]
+		if c != '$' || i+1 >= len(template) {
+			result.WriteByte(c)
+			i++
+			continue
+		}
+
+		next := template[i+1]
+		switch {
+		case next == '$':
+			// $$ - экранированный символ доллара
+			result.WriteByte('$')
+			i += 2
+
+		case next == '{':
+			end, err := findClosingBrace(template, i+2)
+			if err != nil {
+				return "", err
+			}
+			value, err := interpolateBraced(template[i+2:end], lookup, unset)
+			if err != nil {
+				return "", err
+			}
+			result.WriteString(value)
+			i = end + 1
+
+		case isVariableStart(next):
+			j := i + 2
+			for j < len(template) && isVariableChar(template[j]) {
+				j++
+			}
+			value, found := lookup(template[i+1 : j])
+			if !found && unset != nil {
+				unset(template[i+1 : j])
+			}
+			result.WriteString(value)
+			i = j
+
+		default:
+			result.WriteByte(c)
+			i++
+		}
+	}
+
+	return result.String(), nil
+}
+
+// findClosingBrace ищет закрывающую скобку для ${ с учетом вложенных подстановок
+func findClosingBrace(template string, start int) (int, error) {
+	depth := 1
+	for i := start; i < len(template); i++ {
+		switch template[i] {
+		case '$':
+			if i+1 < len(template) {
+				if template[i+1] == '{' {
+					depth++
+					i++
+				} else if template[i+1] == '$' {
+					i++
+				}
+			}
+		case '}':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid interpolation format: %q: missing closing brace", template)
+}
+
+// interpolateBraced вычисляет выражение внутри ${...}
+func interpolateBraced(expr string, lookup LookupFunc, unset func(name string)) (string, error) {
+	nameEnd := 0
+	for nameEnd < len(expr) && isVariableChar(expr[nameEnd]) {
+		nameEnd++
+	}
+	name := expr[:nameEnd]
+	if name == "" || !isVariableStart(name[0]) {
+		return "", fmt.Errorf("invalid interpolation format: ${%s}: invalid variable name", expr)
+	}
+
+	value, found := lookup(name)
+	if nameEnd == len(expr) {
+		if !found && unset != nil {
+			unset(name)
+		}
+		return value, nil
+	}
+
+	rest := expr[nameEnd:]
+	checkEmpty := false
+	if rest[0] == ':' {
+		checkEmpty = true
+		rest = rest[1:]
+	}
+	if rest == "" {
+		return "", fmt.Errorf("invalid interpolation format: ${%s}: missing modifier", expr)
+	}
+
+	modifier, word := rest[0], rest[1:]
+	set := found && (!checkEmpty || value != "")
+
+	switch modifier {
+	case '-':
+		if set {
+			return value, nil
+		}
+		return interpolate(word, lookup, unset)
+
+	case '+':
+		if set {
+			return interpolate(word, lookup, unset)
+		}
+		return "", nil
+
+	case '?':
+		if set {
+			return value, nil
+		}
+		message, err := interpolate(word, lookup, unset)
+		if err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("required variable %s is missing a value: %s", name, message)
+
+	default:
+		return "", fmt.Errorf("invalid interpolation format: ${%s}: unsupported modifier %q", expr, modifier)
+	}
+}
+
+// isVariableStart проверяет, может ли символ начинать имя переменной
+func isVariableStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isVariableChar проверяет, может ли символ входить в имя переменной
+func isVariableChar(c byte) bool {
+	return isVariableStart(c) || (c >= '0' && c <= '9')
+}
+
+// interpolateNode рекурсивно подставляет переменные во все скалярные значения дерева YAML.
+// Ключи отображений не интерполируются, алиасы обрабатываются через их якоря.
+// Незаданные переменные без значения по умолчанию добавляются в диагностики как предупреждения
+func (p *ComposeParser) interpolateNode(ctx *parseContext, node *yaml.Node, path string, lookup LookupFunc, file string) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := p.interpolateNode(ctx, child, path, lookup, file); err != nil {
+				return err
+			}
+		}
+
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			childPath := node.Content[i].Value
+			if path != "" {
+				childPath = path + "." + childPath
+			}
+			if err := p.interpolateNode(ctx, node.Content[i+1], childPath, lookup, file); err != nil {
+				return err
+			}
+		}
+
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			if err := p.interpolateNode(ctx, child, fmt.Sprintf("%s[%d]", path, i), lookup, file); err != nil {
+				return err
+			}
+		}
+
+	case yaml.ScalarNode:
+		if !strings.Contains(node.Value, "$") {
+			return nil
+		}
+		value, err := interpolate(node.Value, lookup, func(name string) {
+			warning := newValidationError(WarnCodeUnsetVariable, node, path, "variable %s is not set, defaulting to a blank string", name)
+			ctx.diagnose(SeverityWarning, warning, file)
+		})
+		if err != nil {
+			// В режиме WithErrorTolerance значение остается без подстановки
+			return p.tolerate(ctx, newValidationError(ErrCodeInterpolation, node, path, "%v", err), file)
+		}
+		if value != node.Value {
+			// Положение конца в исходном тексте вычисляется по значению до подстановки
+			ctx.recordRawEnd(node, file)
+			node.Value = value
+			// Тип незакавыченного значения определяется заново после подстановки, чтобы
+			// числа и логические значения проверялись схемой. Подстановка всегда дает строку,
+			// поэтому пустой результат и значения вида null и ~ остаются строками, а не null
+			if node.Style&(yaml.TaggedStyle|yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+				node.Tag = ""
+				if node.ShortTag() == "!!null" {
+					node.Tag = "!!str"
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package compose_parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	lookup := MapLookup(map[string]string{
+		"TAG":   "1.25",
+		"EMPTY": "",
+		"HOST":  "db",
+		"PORT":  "5432",
+	})
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"no variables", "nginx:latest", "nginx:latest"},
+		{"braced", "nginx:${TAG}", "nginx:1.25"},
+		{"unbraced", "nginx:$TAG", "nginx:1.25"},
+		{"unbraced stops at invalid char", "$HOST-$PORT", "db-5432"},
+		{"unset", "a${MISSING}b", "ab"},
+		{"escaped dollar", "cost: $$5", "cost: $5"},
+		{"escaped braces", "$${TAG}", "${TAG}"},
+		{"trailing dollar", "price$", "price$"},
+		{"dollar before non-name", "$-x", "$-x"},
+		{"default unset", "${MISSING-latest}", "latest"},
+		{"default empty", "${EMPTY-latest}", ""},
+		{"colon default unset", "${MISSING:-latest}", "latest"},
+		{"colon default empty", "${EMPTY:-latest}", "latest"},
+		{"colon default set", "${TAG:-latest}", "1.25"},
+		{"empty default", "${MISSING:-}", ""},
+		{"replacement set", "${TAG+set}", "set"},
+		{"replacement empty", "${EMPTY+set}", "set"},
+		{"replacement unset", "${MISSING+set}", ""},
+		{"colon replacement empty", "${EMPTY:+set}", ""},
+		{"colon replacement set", "${TAG:+set}", "set"},
+		{"required set", "${TAG?missing}", "1.25"},
+		{"required empty", "${EMPTY?missing}", ""},
+		{"colon required set", "${TAG:?missing}", "1.25"},
+		{"nested default", "${MISSING:-${HOST}:${PORT}}", "db:5432"},
+		{"nested default chain", "${MISSING:-${ALSO_MISSING:-fallback}}", "fallback"},
+		{"default with colon", "${MISSING:-a:b}", "a:b"},
+		{"multiple", "${HOST}:${PORT:-80}/$TAG", "db:5432/1.25"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Interpolate(tt.template, lookup)
+			if err != nil {
+				t.Fatalf("Interpolate(%q): %v", tt.template, err)
+			}
+			if got != tt.want {
+				t.Errorf("Interpolate(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpolateErrors(t *testing.T) {
+	lookup := MapLookup(map[string]string{"EMPTY": ""})
+
+	tests := []struct {
+		name     string
+		template string
+		wantErr  string
+	}{
+		{"required unset", "${MISSING?set MISSING}", "required variable MISSING is missing a value: set MISSING"},
+		{"colon required empty", "${EMPTY:?must not be empty}", "required variable EMPTY is missing a value: must not be empty"},
+		{"missing closing brace", "${TAG", "missing closing brace"},
+		{"empty name", "${}", "invalid variable name"},
+		{"invalid name", "${1TAG}", "invalid variable name"},
+		{"missing modifier", "${TAG:}", "missing modifier"},
+		{"unsupported modifier", "${TAG=x}", "unsupported modifier"},
+		{"error in nested default", "${MISSING:-${OTHER:?nested}}", "required variable OTHER"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Interpolate(tt.template, lookup)
+			if err == nil {
+				t.Fatalf("Interpolate(%q): expected an error", tt.template)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Interpolate(%q) error = %q, want it to contain %q", tt.template, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInterpolateUnsetCallback(t *testing.T) {
+	var unset []string
+	_, err := interpolate("$A ${B} ${C:-x} ${D-y} ${E+z} ${SET}", MapLookup(map[string]string{"SET": "1"}), func(name string) {
+		unset = append(unset, name)
+	})
+	if err != nil {
+		t.Fatalf("interpolate: %v", err)
+	}
+	if want := []string{"A", "B"}; !reflect.DeepEqual(unset, want) {
+		t.Errorf("unset variables = %v, want %v", unset, want)
+	}
+}
+
+func TestInterpolateNodeTypes(t *testing.T) {
+	document := `services:
+  web:
+    image: nginx:${TAG}
+    cpu_shares: ${SHARES}
+    read_only: ${READ_ONLY}
+    environment:
+      EMPTY: ${EMPTY}
+      NULL_LIKE: ${NULL_VALUE}
+      UNSET: ${UNSET}
+      QUOTED: "${SHARES}"
+      ESCAPED: $${TAG}
+`
+	parser := NewComposeParser(WithEnvironment(map[string]string{
+		"TAG":        "1.25",
+		"SHARES":     "512",
+		"READ_ONLY":  "true",
+		"EMPTY":      "",
+		"NULL_VALUE": "null",
+	}))
+	project, err := parser.ParseYAML([]byte(document))
+	if err != nil {
+		t.Fatalf("ParseYAML: %v", err)
+	}
+
+	web := project.Services["web"]
+	if web.Image != "nginx:1.25" {
+		t.Errorf("image = %q, want nginx:1.25", web.Image)
+	}
+	if web.CPUShares != 512 {
+		t.Errorf("cpu_shares = %d, want 512", web.CPUShares)
+	}
+	if !web.ReadOnly {
+		t.Error("read_only = false, want true")
+	}
+
+	wantEnvironment := map[string]string{
+		"EMPTY":     "",
+		"NULL_LIKE": "null",
+		"UNSET":     "",
+		"QUOTED":    "512",
+		"ESCAPED":   "${TAG}",
+	}
+	if !reflect.DeepEqual(web.Environment, wantEnvironment) {
+		t.Errorf("environment = %v, want %v", web.Environment, wantEnvironment)
+	}
+	for _, variable := range web.EnvironmentVariables {
+		if variable.IsPassThrough() {
+			t.Errorf("%s: interpolated value must not be pass-through", variable.Name)
+		}
+	}
+
+	var warned bool
+	for _, diagnostic := range project.Diagnostics {
+		if diagnostic.Code == WarnCodeUnsetVariable && diagnostic.Path == "services.web.environment.UNSET" {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Errorf("no unset variable warning for UNSET, diagnostics: %v", project.Diagnostics)
+	}
+}
+
+func TestInterpolateNodeError(t *testing.T) {
+	document := "services:\n  web:\n    image: ${IMAGE:?image is required}\n"
+
+	_, err := NewComposeParser(WithEnvironment(map[string]string{})).ParseYAML([]byte(document))
+	if err == nil {
+		t.Fatal("expected an interpolation error")
+	}
+	if !strings.Contains(err.Error(), "services.web.image") || !strings.Contains(err.Error(), "image is required") {
+		t.Errorf("error = %q, want path services.web.image and the message", err)
+	}
+
+	project, err := NewComposeParser(WithEnvironment(map[string]string{}), WithoutInterpolation()).ParseYAML([]byte(document))
+	if err != nil {
+		t.Fatalf("ParseYAML without interpolation: %v", err)
+	}
+	if image := project.Services["web"].Image; image != "${IMAGE:?image is required}" {
+		t.Errorf("image without interpolation = %q", image)
+	}
+}
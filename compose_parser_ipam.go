@@ -0,0 +1,103 @@
+package compose_parser
+
+import (
+	"fmt"
+	"net/netip"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseIPAM парсит настройки распределения адресов сети. Подсети, диапазоны и адреса
+// проверяются на корректность, шлюз и диапазон должны принадлежать подсети пула
+func (p *ComposeParser) parseIPAM(node *yaml.Node, path string) (*IPAMConfig, error) {
+	ipam := &IPAMConfig{}
+
+	d := p.newNodeDecoder(node, path)
+	ipam.Driver = d.stringField("driver")
+	d.field("config", func(node *yaml.Node, path string) error {
+		return forEachItem(node, path, func(item *yaml.Node, path string) error {
+			pool, err := p.parseIPAMPool(item, path)
+			if err != nil {
+				return err
+			}
+			ipam.Config = append(ipam.Config, *pool)
+			return nil
+		})
+	})
+	ipam.Options = d.keyValueField("options")
+
+	if err := d.Err(); err != nil {
+		return nil, err
+	}
+
+	return ipam, nil
+}
+
+// parseIPAMPool парсит пул адресов сети
+func (p *ComposeParser) parseIPAMPool(node *yaml.Node, path string) (*IPAMPool, error) {
+	pool := &IPAMPool{}
+
+	d := p.newNodeDecoder(node, path)
+	pool.Subnet = d.stringField("subnet")
+	pool.IPRange = d.stringField("ip_range")
+	pool.Gateway = d.stringField("gateway")
+	pool.AuxAddresses = d.keyValueField("aux_addresses")
+	if err := d.Err(); err != nil {
+		return nil, err
+	}
+
+	// Значения с неподставленными переменными не проверяются
+	if p.disableInterpolate {
+		return pool, nil
+	}
+
+	var subnet netip.Prefix
+	if pool.Subnet != "" {
+		var err error
+		if subnet, err = netip.ParsePrefix(pool.Subnet); err != nil {
+			return nil, invalidValueError(d.value("subnet"), d.fieldPath("subnet"), "invalid subnet %q, expected CIDR notation", pool.Subnet)
+		}
+	}
+
+	if pool.IPRange != "" {
+		ipRange, err := netip.ParsePrefix(pool.IPRange)
+		if err != nil {
+			return nil, invalidValueError(d.value("ip_range"), d.fieldPath("ip_range"), "invalid ip_range %q, expected CIDR notation", pool.IPRange)
+		}
+		if subnet.IsValid() && !prefixContains(subnet, ipRange) {
+			return nil, invalidValueError(d.value("ip_range"), d.fieldPath("ip_range"), "ip_range %s is outside of subnet %s", pool.IPRange, pool.Subnet)
+		}
+	}
+
+	if pool.Gateway != "" {
+		if err := checkPoolAddress(subnet, pool.Gateway); err != nil {
+			return nil, invalidValueError(d.value("gateway"), d.fieldPath("gateway"), "gateway %v", err)
+		}
+	}
+
+	auxNode := d.value("aux_addresses")
+	for host, address := range pool.AuxAddresses {
+		if err := checkPoolAddress(subnet, address); err != nil {
+			return nil, invalidValueError(mappingValue(auxNode, host), joinPath(d.fieldPath("aux_addresses"), host), "aux address %v", err)
+		}
+	}
+
+	return pool, nil
+}
+
+// checkPoolAddress проверяет, что адрес корректен и принадлежит подсети, если она задана
+func checkPoolAddress(subnet netip.Prefix, value string) error {
+	address, err := netip.ParseAddr(value)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid IP address", value)
+	}
+	if subnet.IsValid() && !subnet.Contains(address) {
+		return fmt.Errorf("%s is outside of subnet %s", value, subnet)
+	}
+	return nil
+}
+
+// prefixContains проверяет, что подсеть inner целиком входит в подсеть outer
+func prefixContains(outer, inner netip.Prefix) bool {
+	return outer.Bits() <= inner.Bits() && outer.Contains(inner.Addr())
+}
@@ -0,0 +1,590 @@
+package compose_parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// jsonSchema представляет загруженную JSON-схему. Проверка выполняется напрямую
+// по дереву YAML, чтобы ошибки указывали на положение элементов в исходном файле.
+// Поддерживается подмножество JSON Schema, используемое схемами Compose
+type jsonSchema struct {
+	name string
+	root interface{}
+
+	mu       sync.Mutex
+	patterns map[string]*regexp.Regexp
+}
+
+// schemaAddition описывает поле, которое парсер поддерживает, но встроенная схема еще не описывает
+type schemaAddition struct {
+	ref      string // Ссылка на подсхему отображения, например "#/definitions/network"
+	field    string // Имя поля
+	property string // Подсхема поля в формате JSON
+}
+
+// parseJSONSchema разбирает JSON-схему
+func parseJSONSchema(name string, data []byte) (*jsonSchema, error) {
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema %s: %w", name, err)
+	}
+	return &jsonSchema{name: name, root: root, patterns: make(map[string]*regexp.Regexp)}, nil
+}
+
+// extend добавляет поля в свойства подсхем
+func (s *jsonSchema) extend(additions []schemaAddition) error {
+	for _, addition := range additions {
+		target, err := s.resolveRef(addition.ref)
+		if err != nil {
+			return err
+		}
+		object, ok := target.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("schema %s: %s is not an object", s.name, addition.ref)
+		}
+		properties, ok := object["properties"].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("schema %s: %s has no properties", s.name, addition.ref)
+		}
+
+		var property interface{}
+		if err := json.Unmarshal([]byte(addition.property), &property); err != nil {
+			return fmt.Errorf("schema %s: invalid property %s: %w", s.name, addition.field, err)
+		}
+		properties[addition.field] = property
+	}
+	return nil
+}
+
+// validate проверяет узел документа по корню схемы и возвращает все найденные нарушения
+func (s *jsonSchema) validate(node *yaml.Node) []error {
+	return s.validateNode(s.root, node, "")
+}
+
+// validateNode проверяет узел по подсхеме. Несоответствие типа прекращает проверку
+// остальных ключевых слов подсхемы для этого узла
+func (s *jsonSchema) validateNode(schema interface{}, node *yaml.Node, path string) []error {
+	switch schema := schema.(type) {
+	case bool:
+		if !schema {
+			return []error{invalidValueError(node, path, "value is not allowed")}
+		}
+		return nil
+	case map[string]interface{}:
+		return s.validateKeywords(schema, node, path)
+	default:
+		return nil
+	}
+}
+
+// validateKeywords проверяет узел по ключевым словам подсхемы
+func (s *jsonSchema) validateKeywords(schema map[string]interface{}, node *yaml.Node, path string) []error {
+	// Как и в draft-04/07, ключевые слова рядом с $ref не учитываются:
+	// схемы Compose рассчитаны на такое поведение
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := s.resolveRef(ref)
+		if err != nil {
+			return []error{err}
+		}
+		return s.validateNode(target, node, path)
+	}
+
+	var errs []error
+	if types, ok := schema["type"]; ok && !matchesSchemaType(node, types) {
+		return append(errs, newTypeError(node, path, describeSchemaTypes(types)))
+	}
+
+	if values, ok := schema["enum"].([]interface{}); ok && !matchesEnum(node, values) {
+		errs = append(errs, invalidValueError(node, path, "must be one of %s", describeEnum(values)))
+	}
+	if value, ok := schema["const"]; ok && !jsonValueEqual(node, value) {
+		errs = append(errs, invalidValueError(node, path, "must be %s", describeEnum([]interface{}{value})))
+	}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		errs = append(errs, s.validateScalar(schema, node, path)...)
+	case yaml.MappingNode:
+		errs = append(errs, s.validateMapping(schema, node, path)...)
+	case yaml.SequenceNode:
+		errs = append(errs, s.validateSequence(schema, node, path)...)
+	}
+
+	if branches, ok := schema["allOf"].([]interface{}); ok {
+		for _, branch := range branches {
+			errs = append(errs, s.validateNode(branch, node, path)...)
+		}
+	}
+	if branches, ok := schema["anyOf"].([]interface{}); ok {
+		errs = append(errs, s.validateAlternatives(branches, node, path, false)...)
+	}
+	if branches, ok := schema["oneOf"].([]interface{}); ok {
+		errs = append(errs, s.validateAlternatives(branches, node, path, true)...)
+	}
+	if not, ok := schema["not"]; ok && len(s.validateNode(not, node, path)) == 0 {
+		errs = append(errs, invalidValueError(node, path, "value is not allowed"))
+	}
+
+	return errs
+}
+
+// validateScalar проверяет ограничения строк и чисел
+func (s *jsonSchema) validateScalar(schema map[string]interface{}, node *yaml.Node, path string) []error {
+	var errs []error
+
+	switch schemaNodeType(node) {
+	case "string":
+		length := float64(len([]rune(node.Value)))
+		if limit, ok := schema["minLength"].(float64); ok && length < limit {
+			errs = append(errs, invalidValueError(node, path, "must be at least %v characters long", limit))
+		}
+		if limit, ok := schema["maxLength"].(float64); ok && length > limit {
+			errs = append(errs, invalidValueError(node, path, "must be at most %v characters long", limit))
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := s.pattern(pattern)
+			if err != nil {
+				return append(errs, err)
+			}
+			if !re.MatchString(node.Value) {
+				errs = append(errs, invalidValueError(node, path, "must match pattern %q", pattern))
+			}
+		}
+		if format, ok := schema["format"].(string); ok {
+			if err := checkSchemaFormat(format, node.Value); err != nil {
+				errs = append(errs, invalidValueError(node, path, "%v", err))
+			}
+		}
+
+	case "integer", "number":
+		value, err := strconv.ParseFloat(node.Value, 64)
+		if err != nil {
+			return nil
+		}
+		if limit, ok := schema["minimum"].(float64); ok && value < limit {
+			errs = append(errs, invalidValueError(node, path, "must be greater than or equal to %v", limit))
+		}
+		if limit, ok := schema["maximum"].(float64); ok && value > limit {
+			errs = append(errs, invalidValueError(node, path, "must be less than or equal to %v", limit))
+		}
+	}
+
+	return errs
+}
+
+// validateMapping проверяет свойства отображения
+func (s *jsonSchema) validateMapping(schema map[string]interface{}, node *yaml.Node, path string) []error {
+	var errs []error
+
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, field := range required {
+			if name, ok := field.(string); ok && mappingValue(node, name) == nil {
+				errs = append(errs, requiredFieldError(node, path, name))
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	patternProperties, _ := schema["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := schema["additionalProperties"]
+
+	// Порядок шаблонов фиксирован, чтобы ошибки не зависели от порядка обхода map
+	patterns := make([]string, 0, len(patternProperties))
+	for pattern := range patternProperties {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		key := keyNode.Value
+		keyPath := joinPath(path, key)
+
+		matched := false
+		if property, ok := properties[key]; ok {
+			matched = true
+			errs = append(errs, s.validateNode(property, valueNode, keyPath)...)
+		}
+		for _, pattern := range patterns {
+			re, err := s.pattern(pattern)
+			if err != nil {
+				return append(errs, err)
+			}
+			if re.MatchString(key) {
+				matched = true
+				errs = append(errs, s.validateNode(patternProperties[pattern], valueNode, keyPath)...)
+			}
+		}
+		if matched || !hasAdditional {
+			continue
+		}
+
+		if allowed, ok := additional.(bool); ok && !allowed {
+			if namePattern := namePattern(properties, patterns); namePattern != "" {
+				// Отображение имен (services, networks и т.д.): ключ не подходит под шаблон имени
+				errs = append(errs, invalidValueError(keyNode, keyPath, "invalid name %q, must match %s", key, namePattern))
+				continue
+			}
+			errs = append(errs, newUnknownFieldError(keyNode, path, key, suggestField(key, properties)))
+			continue
+		}
+		errs = append(errs, s.validateNode(additional, valueNode, keyPath)...)
+	}
+
+	return errs
+}
+
+// namePattern возвращает шаблон имен для отображений без фиксированных свойств,
+// например services или networks. Шаблон полей расширений x- не учитывается
+func namePattern(properties map[string]interface{}, patterns []string) string {
+	if len(properties) > 0 {
+		return ""
+	}
+	for _, pattern := range patterns {
+		if pattern != "^x-" {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// validateSequence проверяет элементы списка
+func (s *jsonSchema) validateSequence(schema map[string]interface{}, node *yaml.Node, path string) []error {
+	var errs []error
+
+	count := float64(len(node.Content))
+	if limit, ok := schema["minItems"].(float64); ok && count < limit {
+		errs = append(errs, invalidValueError(node, path, "must contain at least %v items", limit))
+	}
+	if limit, ok := schema["maxItems"].(float64); ok && count > limit {
+		errs = append(errs, invalidValueError(node, path, "must contain at most %v items", limit))
+	}
+
+	switch items := schema["items"].(type) {
+	case []interface{}:
+		// Кортеж: каждый элемент проверяется своей подсхемой
+		for i, item := range node.Content {
+			if i < len(items) {
+				errs = append(errs, s.validateNode(items[i], item, indexPath(path, i))...)
+			}
+		}
+	case nil:
+	default:
+		for i, item := range node.Content {
+			errs = append(errs, s.validateNode(items, item, indexPath(path, i))...)
+		}
+	}
+
+	if unique, ok := schema["uniqueItems"].(bool); ok && unique {
+		for i := 1; i < len(node.Content); i++ {
+			for j := 0; j < i; j++ {
+				if nodesEqual(node.Content[i], node.Content[j]) {
+					errs = append(errs, invalidValueError(node.Content[i], indexPath(path, i), "duplicate item %s", describeNode(node.Content[i])))
+					break
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateAlternatives проверяет узел по вариантам anyOf или oneOf (exactly).
+// Если ни один вариант не подходит, возвращаются ошибки варианта, тип которого
+// совпадает с типом узла, а если таких нет - ошибка типа со всеми допустимыми типами
+func (s *jsonSchema) validateAlternatives(branches []interface{}, node *yaml.Node, path string, exactly bool) []error {
+	var best []error
+	var expected []string
+	valid := 0
+
+	for _, branch := range branches {
+		errs := s.validateNode(branch, node, path)
+		if len(errs) == 0 {
+			valid++
+			continue
+		}
+
+		// Вариант другого типа не рассматривается как кандидат для сообщения
+		if len(errs) == 1 {
+			if typeErr, ok := errs[0].(*TypeError); ok && typeErr.Path == path {
+				expected = append(expected, strings.Split(typeErr.Expected, " or ")...)
+				continue
+			}
+		}
+		if best == nil || len(errs) < len(best) {
+			best = errs
+		}
+	}
+
+	switch {
+	case valid == 1 || (valid > 1 && !exactly):
+		return nil
+	case valid > 1:
+		return []error{invalidValueError(node, path, "must match exactly one of the allowed forms")}
+	case best != nil:
+		return best
+	default:
+		return []error{newTypeError(node, path, joinUnique(expected, " or "))}
+	}
+}
+
+// resolveRef возвращает подсхему по локальной ссылке вида "#/definitions/service".
+// Элементы массивов (oneOf, items) адресуются индексом
+func (s *jsonSchema) resolveRef(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("schema %s: unsupported reference %q", s.name, ref)
+	}
+
+	current := s.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if part == "" {
+			continue
+		}
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		switch node := current.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if current, ok = node[part]; !ok {
+				return nil, fmt.Errorf("schema %s: unresolved reference %q", s.name, ref)
+			}
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("schema %s: unresolved reference %q", s.name, ref)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("schema %s: unresolved reference %q", s.name, ref)
+		}
+	}
+	return current, nil
+}
+
+// pattern возвращает скомпилированное регулярное выражение схемы
+func (s *jsonSchema) pattern(pattern string) (*regexp.Regexp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if re, ok := s.patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("schema %s: invalid pattern %q: %w", s.name, pattern, err)
+	}
+	s.patterns[pattern] = re
+	return re, nil
+}
+
+// schemaNodeType возвращает тип JSON значения узла: null, boolean, integer, number,
+// string, object или array
+func schemaNodeType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+
+	tag := node.ShortTag()
+	if !strings.HasPrefix(tag, "!!") {
+		// Пользовательские теги (!reset, !override) не меняют тип значения
+		tag = (&yaml.Node{Kind: yaml.ScalarNode, Value: node.Value, Style: node.Style &^ yaml.TaggedStyle}).ShortTag()
+	}
+
+	switch tag {
+	case "!!null":
+		return "null"
+	case "!!bool":
+		return "boolean"
+	case "!!int":
+		return "integer"
+	case "!!float":
+		if value, err := strconv.ParseFloat(node.Value, 64); err == nil && value == math.Trunc(value) && !math.IsInf(value, 0) {
+			return "integer"
+		}
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+// matchesSchemaType проверяет тип узла по ключевому слову type (строка или список типов)
+func matchesSchemaType(node *yaml.Node, types interface{}) bool {
+	nodeType := schemaNodeType(node)
+	matches := func(schemaType interface{}) bool {
+		return schemaType == nodeType || (schemaType == "number" && nodeType == "integer")
+	}
+
+	if list, ok := types.([]interface{}); ok {
+		for _, schemaType := range list {
+			if matches(schemaType) {
+				return true
+			}
+		}
+		return false
+	}
+	return matches(types)
+}
+
+// describeSchemaTypes возвращает описание ожидаемых типов для сообщений об ошибках
+func describeSchemaTypes(types interface{}) string {
+	names := map[string]string{
+		"null":    "null",
+		"boolean": "a boolean",
+		"integer": "an integer",
+		"number":  "a number",
+		"string":  "a string",
+		"object":  "a mapping",
+		"array":   "a list",
+	}
+
+	var list []interface{}
+	if items, ok := types.([]interface{}); ok {
+		list = items
+	} else {
+		list = []interface{}{types}
+	}
+
+	descriptions := make([]string, 0, len(list))
+	for _, schemaType := range list {
+		if name, ok := schemaType.(string); ok {
+			descriptions = append(descriptions, names[name])
+		}
+	}
+	return joinUnique(descriptions, " or ")
+}
+
+// matchesEnum проверяет, совпадает ли значение узла с одним из допустимых значений
+func matchesEnum(node *yaml.Node, values []interface{}) bool {
+	for _, value := range values {
+		if jsonValueEqual(node, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonValueEqual сравнивает скалярный узел со значением из JSON-схемы
+func jsonValueEqual(node *yaml.Node, value interface{}) bool {
+	if node.Kind != yaml.ScalarNode {
+		return false
+	}
+
+	switch value := value.(type) {
+	case nil:
+		return schemaNodeType(node) == "null"
+	case string:
+		return schemaNodeType(node) == "string" && node.Value == value
+	case bool:
+		return schemaNodeType(node) == "boolean" && strings.EqualFold(node.Value, strconv.FormatBool(value))
+	case float64:
+		number, err := strconv.ParseFloat(node.Value, 64)
+		return err == nil && number == value
+	default:
+		return false
+	}
+}
+
+// describeEnum возвращает список допустимых значений для сообщений об ошибках
+func describeEnum(values []interface{}) string {
+	descriptions := make([]string, 0, len(values))
+	for _, value := range values {
+		switch value := value.(type) {
+		case string:
+			descriptions = append(descriptions, strconv.Quote(value))
+		case nil:
+			descriptions = append(descriptions, "null")
+		default:
+			descriptions = append(descriptions, fmt.Sprint(value))
+		}
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// checkSchemaFormat проверяет строку по ключевому слову format. Форматы ports и expose
+// проверяются при разборе портов, неизвестные форматы не проверяются
+func checkSchemaFormat(format string, value string) error {
+	switch format {
+	case "duration":
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("must be a duration, got %q", value)
+		}
+	case "subnet_ip_address":
+		if _, _, err := net.ParseCIDR(value); err != nil {
+			return fmt.Errorf("must be a subnet in CIDR notation, got %q", value)
+		}
+	}
+	return nil
+}
+
+// suggestField возвращает наиболее похожее известное поле для опечатки в имени поля
+// или "", если похожих полей нет. Поля с префиксом x- не предлагаются
+func suggestField(field string, properties map[string]interface{}) string {
+	candidates := make([]string, 0, len(properties))
+	for name := range properties {
+		candidates = append(candidates, name)
+	}
+	sort.Strings(candidates)
+
+	suggestion := ""
+	best := 0
+	for _, candidate := range candidates {
+		distance := levenshtein(strings.ToLower(field), candidate)
+		if distance > 2 && distance > len(field)/3 {
+			continue
+		}
+		if suggestion == "" || distance < best {
+			suggestion, best = candidate, distance
+		}
+	}
+	return suggestion
+}
+
+// levenshtein вычисляет расстояние редактирования между строками
+func levenshtein(a, b string) int {
+	left, right := []rune(a), []rune(b)
+	previous := make([]int, len(right)+1)
+	current := make([]int, len(right)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(left); i++ {
+		current[0] = i
+		for j := 1; j <= len(right); j++ {
+			cost := 1
+			if left[i-1] == right[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(right)]
+}
+
+// joinUnique объединяет непустые строки без повторов, сохраняя порядок
+func joinUnique(values []string, separator string) string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		unique = append(unique, value)
+	}
+	return strings.Join(unique, separator)
+}
@@ -0,0 +1,192 @@
+package compose_parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		wantCode string
+		wantPath string
+		wantMsg  string
+	}{
+		{
+			name:     "valid document",
+			document: "services:\n  web:\n    image: app\n    ports: [\"80:80\"]\n    x-custom: {any: thing}\nx-common: 1\n",
+		},
+		{
+			name:     "unknown field with suggestion",
+			document: "services:\n  web:\n    image: app\n    enviroment: {A: 1}\n",
+			wantCode: ErrCodeUnknownField,
+			wantPath: "services.web.enviroment",
+			wantMsg:  `did you mean "environment"?`,
+		},
+		{
+			name:     "unknown top-level field",
+			document: "services:\n  web:\n    image: app\nservice: {}\n",
+			wantCode: ErrCodeUnknownField,
+			wantPath: "service",
+			wantMsg:  `did you mean "services"?`,
+		},
+		{
+			name:     "type mismatch",
+			document: "services:\n  web:\n    image: app\n    privileged: [yes]\n",
+			wantCode: ErrCodeType,
+			wantPath: "services.web.privileged",
+		},
+		{
+			name:     "enum value",
+			document: "services:\n  web:\n    image: app\n    pull_policy: sometimes\n",
+			wantCode: ErrCodeInvalidValue,
+			wantPath: "services.web.pull_policy",
+		},
+		{
+			name:     "required field",
+			document: "services:\n  web:\n    image: app\n    extends:\n      file: base.yaml\n",
+			wantCode: ErrCodeRequiredField,
+			wantPath: "services.web.extends.service",
+		},
+		{
+			name:     "invalid service name",
+			document: "services:\n  \"web app\":\n    image: app\n",
+			wantCode: ErrCodeInvalidValue,
+			wantPath: "services.web app",
+			wantMsg:  "invalid name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics, err := NewComposeParser().ValidateYAML([]byte(tt.document))
+			if err != nil {
+				t.Fatalf("ValidateYAML: %v", err)
+			}
+			if tt.wantCode == "" {
+				if len(diagnostics) != 0 {
+					t.Errorf("diagnostics = %v, want none", diagnostics)
+				}
+				return
+			}
+
+			for _, diagnostic := range diagnostics {
+				if diagnostic.Code == tt.wantCode && diagnostic.Path == tt.wantPath && strings.Contains(diagnostic.Message, tt.wantMsg) {
+					if diagnostic.Severity != SeverityError || diagnostic.Location == nil {
+						t.Errorf("severity = %q, location = %v", diagnostic.Severity, diagnostic.Location)
+					}
+					return
+				}
+			}
+			t.Errorf("diagnostics = %v, want %s at %s with %q", diagnostics, tt.wantCode, tt.wantPath, tt.wantMsg)
+		})
+	}
+}
+
+func TestSchemaAdditions(t *testing.T) {
+	// Поля, которые парсер поддерживает, а встроенная схема спецификации еще не описывает
+	document := `services:
+  web:
+    image: app
+    networks:
+      front:
+        gw_priority: 10
+        interface_name: eth1
+    volumes:
+      - type: image
+        source: alpine
+        target: /image
+        image:
+          subpath: etc
+    env_file:
+      - path: ./app.env
+        format: raw
+        required: false
+networks:
+  front:
+    enable_ipv4: false
+`
+	diagnostics, err := NewComposeParser().ValidateYAML([]byte(document))
+	if err != nil {
+		t.Fatalf("ValidateYAML: %v", err)
+	}
+	if len(diagnostics) != 0 {
+		t.Errorf("diagnostics = %v, want none", diagnostics)
+	}
+
+	if _, err := NewComposeParser(WithStrict(), WithEnvironment(map[string]string{})).ParseYAML([]byte(document)); err != nil {
+		t.Errorf("ParseYAML in strict mode: %v", err)
+	}
+
+	// Типы добавленных полей проверяются
+	invalid := strings.Replace(document, "gw_priority: 10", "gw_priority: high", 1)
+	diagnostics, err = NewComposeParser().ValidateYAML([]byte(invalid))
+	if err != nil {
+		t.Fatalf("ValidateYAML: %v", err)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Path != "services.web.networks.front.gw_priority" {
+		t.Errorf("diagnostics = %v, want a gw_priority type error", diagnostics)
+	}
+}
+
+func TestStrictUnknownFields(t *testing.T) {
+	document := "services:\n  web:\n    image: app\n    restrat: always\n    x-note: ok\n    deploy:\n      replica: 2\n"
+
+	if _, err := NewComposeParser().ParseYAML([]byte(document)); err != nil {
+		t.Fatalf("ParseYAML without strict mode: %v", err)
+	}
+
+	_, err := NewComposeParser(WithStrict()).ParseYAML([]byte(document))
+	var unknown *UnknownFieldError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("error = %v, want an *UnknownFieldError", err)
+	}
+	if unknown.Field != "restrat" || unknown.Suggestion != "restart" || unknown.Path != "services.web.restrat" {
+		t.Errorf("field = %q, suggestion = %q, path = %q", unknown.Field, unknown.Suggestion, unknown.Path)
+	}
+	if unknown.Location == nil || unknown.Location.Line != 4 {
+		t.Errorf("location = %v, want line 4", unknown.Location)
+	}
+
+	// В режиме WithErrorTolerance собираются все неизвестные поля
+	project, err := NewComposeParser(WithStrict(), WithErrorTolerance()).ParseYAML([]byte(document))
+	if err != nil {
+		t.Fatalf("ParseYAML with error tolerance: %v", err)
+	}
+	var got []string
+	for _, diagnostic := range project.Diagnostics {
+		if diagnostic.Code == ErrCodeUnknownField {
+			got = append(got, diagnostic.Path)
+		}
+	}
+	if want := "services.web.restrat, services.web.deploy.replica"; strings.Join(got, ", ") != want {
+		t.Errorf("unknown fields = %q, want %q", got, want)
+	}
+}
+
+func TestSuggestField(t *testing.T) {
+	properties := map[string]interface{}{
+		"image": nil, "environment": nil, "env_file": nil, "restart": nil, "networks": nil,
+	}
+
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{"imgae", "image"},
+		{"Image", "image"},
+		{"enviroment", "environment"},
+		{"envfile", "env_file"},
+		{"network", "networks"},
+		{"x", ""},
+		{"volumes", ""},
+	}
+
+	for _, tt := range tests {
+		if got := suggestField(tt.field, properties); got != tt.want {
+			t.Errorf("suggestField(%q) = %q, want %q", tt.field, got, tt.want)
+		}
+	}
+}
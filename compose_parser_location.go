@@ -0,0 +1,216 @@
+package compose_parser
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// String возвращает положение в формате file:line:column
+func (l SourceLocation) String() string {
+	if l.Column == 0 {
+		// Для синтаксических ошибок YAML известна только строка
+		if l.File == "" {
+			return fmt.Sprintf("%d", l.Line)
+		}
+		return fmt.Sprintf("%s:%d", l.File, l.Line)
+	}
+	if l.File == "" {
+		return fmt.Sprintf("%d:%d", l.Line, l.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Column)
+}
+
+// LocationOf возвращает положение элемента в исходном файле по пути,
+// например "services.web", "services.web.ports[2]" или "services.web.environment.DEBUG"
+func (c *ComposeProjectConfig) LocationOf(path string) (*SourceLocation, bool) {
+	location, ok := c.Locations[path]
+	if !ok {
+		return nil, false
+	}
+	return &location, true
+}
+
+// registerSource запоминает файл, из которого загружены узел и все вложенные в него узлы
+func (ctx *parseContext) registerSource(node *yaml.Node, file string) {
+	if node == nil {
+		return
+	}
+	ctx.sources[node] = file
+	for _, child := range node.Content {
+		ctx.registerSource(child, file)
+	}
+}
+
+// copyNode создает глубокую копию узла, сохраняя файлы, из которых загружены исходные узлы
+func (ctx *parseContext) copyNode(node *yaml.Node) *yaml.Node {
+	clone := copyNode(node)
+	ctx.copySources(node, clone)
+	return clone
+}
+
+// copySources переносит файлы исходных узлов на узлы копии
+func (ctx *parseContext) copySources(original, clone *yaml.Node) {
+	if original == nil || clone == nil {
+		return
+	}
+	if file, ok := ctx.sources[original]; ok {
+		ctx.sources[clone] = file
+	}
+	for i := range original.Content {
+		ctx.copySources(original.Content[i], clone.Content[i])
+	}
+}
+
+// sourceFile возвращает файл, из которого загружен узел. Для узлов, созданных при слиянии,
+// используется файл первого вложенного узла
+func (ctx *parseContext) sourceFile(node *yaml.Node) string {
+	for node != nil {
+		if file, ok := ctx.sources[node]; ok {
+			return file
+		}
+		if len(node.Content) == 0 {
+			break
+		}
+		node = node.Content[0]
+	}
+	return ""
+}
+
+// collectLocations заполняет положения всех элементов документа по их путям.
+// Положение элемента отображения начинается с ключа и заканчивается концом значения.
+// Если скалярное значение переопределено в другом файле, положение указывает на значение
+func (ctx *parseContext) collectLocations(keyNode, valueNode *yaml.Node, path string) {
+	startNode := valueNode
+	if keyNode != nil && (valueNode.Kind != yaml.ScalarNode || ctx.sourceFile(keyNode) == ctx.sourceFile(valueNode)) {
+		startNode = keyNode
+	}
+	if path != "" {
+		endLine, endColumn := ctx.nodeEnd(valueNode)
+		ctx.locations[path] = SourceLocation{
+			File:      ctx.sourceFile(startNode),
+			Line:      startNode.Line,
+			Column:    startNode.Column,
+			EndLine:   endLine,
+			EndColumn: endColumn,
+		}
+	}
+
+	switch valueNode.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(valueNode.Content); i += 2 {
+			ctx.collectLocations(valueNode.Content[i], valueNode.Content[i+1], joinPath(path, valueNode.Content[i].Value))
+		}
+	case yaml.SequenceNode:
+		for i, item := range valueNode.Content {
+			ctx.collectLocations(nil, item, indexPath(path, i))
+		}
+	}
+}
+
+// locationAt возвращает положение элемента по пути или nil, если положение неизвестно
+func (ctx *parseContext) locationAt(path string) *SourceLocation {
+	location, ok := ctx.locations[path]
+	if !ok {
+		return nil
+	}
+	return &location
+}
+
+// scalarPosition определяет позицию в файле. Копии узлов сохраняют строку и колонку,
+// поэтому скаляр определяется по файлу и позиции начала, а не по указателю
+type scalarPosition struct {
+	file   string
+	line   int
+	column int
+}
+
+// recordRawEnd запоминает конечную позицию скаляра до изменения его значения подстановкой
+func (ctx *parseContext) recordRawEnd(node *yaml.Node, file string) {
+	start := scalarPosition{file: file, line: node.Line, column: node.Column}
+	if _, ok := ctx.rawEnds[start]; ok {
+		return
+	}
+	line, column := nodeEnd(node)
+	ctx.rawEnds[start] = scalarPosition{line: line, column: column}
+}
+
+// recordBlockEnds запоминает конечные позиции блочных скаляров (| и >) документа по последней
+// строке их содержимого в исходном тексте data. Значение блочного скаляра не сохраняет отступы
+// и переносы строк свернутого скаляра, поэтому по нему конец не определяется
+func (ctx *parseContext) recordBlockEnds(node *yaml.Node, data []byte, file string) {
+	ctx.recordBlockEndsIn(node, strings.Split(string(data), "\n"), file)
+}
+
+// recordBlockEndsIn запоминает конечные позиции блочных скаляров узла и вложенных узлов
+func (ctx *parseContext) recordBlockEndsIn(node *yaml.Node, lines []string, file string) {
+	for _, child := range node.Content {
+		ctx.recordBlockEndsIn(child, lines, file)
+	}
+	if node.Kind != yaml.ScalarNode || node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) == 0 || node.Line > len(lines) {
+		return
+	}
+
+	// Содержимое блока имеет больший отступ, чем строка с индикатором
+	indicatorIndent := lineIndent(lines[node.Line-1])
+	end := scalarPosition{}
+	for i := node.Line; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t\r")
+		if line == "" {
+			continue
+		}
+		if lineIndent(line) <= indicatorIndent {
+			break
+		}
+		end = scalarPosition{line: i + 1, column: utf8.RuneCountInString(line) + 1}
+	}
+	if end.line != 0 {
+		ctx.rawEnds[scalarPosition{file: file, line: node.Line, column: node.Column}] = end
+	}
+}
+
+// lineIndent возвращает число пробелов в начале строки
+func lineIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// nodeEnd вычисляет конечную позицию узла в исходном тексте. Для интерполированных
+// и блочных скаляров используется позиция, вычисленная по исходному тексту при загрузке
+func (ctx *parseContext) nodeEnd(node *yaml.Node) (int, int) {
+	if len(node.Content) > 0 {
+		return ctx.nodeEnd(node.Content[len(node.Content)-1])
+	}
+	if end, ok := ctx.rawEnds[scalarPosition{file: ctx.sourceFile(node), line: node.Line, column: node.Column}]; ok {
+		return end.line, end.column
+	}
+	return nodeEnd(node)
+}
+
+// nodeEnd вычисляет конечную позицию узла: для скаляров по длине значения,
+// для коллекций по последнему вложенному узлу. Закрывающие скобки и кавычки
+// потоковой записи учитываются приблизительно, конец блочных скаляров уточняется
+// по исходному тексту (см. recordBlockEnds)
+func nodeEnd(node *yaml.Node) (int, int) {
+	if len(node.Content) > 0 {
+		return nodeEnd(node.Content[len(node.Content)-1])
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		// Пустая коллекция в потоковой записи: {} или []
+		return node.Line, node.Column + 2
+	}
+
+	switch {
+	case node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+		// Блочный скаляр начинается со строки после индикатора | или >
+		lines := strings.Split(strings.TrimRight(node.Value, "\n"), "\n")
+		return node.Line + len(lines), node.Column + utf8.RuneCountInString(lines[len(lines)-1])
+	case node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0:
+		return node.Line, node.Column + utf8.RuneCountInString(node.Value) + 2
+	default:
+		return node.Line, node.Column + utf8.RuneCountInString(node.Value)
+	}
+}
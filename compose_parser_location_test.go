@@ -0,0 +1,97 @@
+package compose_parser
+
+import "testing"
+
+const locationDocument = `services:
+  web:
+    image: nginx
+    command: |
+      first line
+        second line
+
+    entrypoint: >
+      folded
+      text
+    environment:
+      DEBUG: "1"
+      PORT: ${WEB_PORT}
+    labels:
+      - "a=b"
+    healthcheck:
+      test:
+        - CMD
+        - |
+          curl -f
+          http://localhost
+  worker:
+    image: worker
+    environment:
+      - MODE=batch
+      - TOKEN
+`
+
+func TestLocations(t *testing.T) {
+	parser := NewComposeParser(WithEnvironment(map[string]string{"WEB_PORT": "8080"}))
+	project, err := parser.ParseYAML([]byte(locationDocument))
+	if err != nil {
+		t.Fatalf("ParseYAML: %v", err)
+	}
+
+	tests := []struct {
+		path                             string
+		line, column, endLine, endColumn int
+	}{
+		{"services.web.image", 3, 5, 3, 17},
+		{"services.web.command", 4, 5, 6, 20},
+		{"services.web.entrypoint", 8, 5, 10, 11},
+		{"services.web.environment.DEBUG", 12, 7, 12, 17},
+		{"services.web.environment.PORT", 13, 7, 13, 24},
+		{"services.web.healthcheck.test[1]", 19, 11, 21, 27},
+		{"services.worker.environment[1]", 26, 9, 26, 14},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			location, ok := project.LocationOf(tt.path)
+			if !ok {
+				t.Fatalf("no location for %s", tt.path)
+			}
+			got := [4]int{location.Line, location.Column, location.EndLine, location.EndColumn}
+			want := [4]int{tt.line, tt.column, tt.endLine, tt.endColumn}
+			if got != want {
+				t.Errorf("location = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestEnvironmentVariableLocations(t *testing.T) {
+	project, err := NewComposeParser(WithEnvironment(map[string]string{"WEB_PORT": "8080"})).ParseYAML([]byte(locationDocument))
+	if err != nil {
+		t.Fatalf("ParseYAML: %v", err)
+	}
+
+	tests := []struct {
+		service string
+		index   int
+		name    string
+		line    int
+		column  int
+	}{
+		{"web", 0, "DEBUG", 12, 7},
+		{"web", 1, "PORT", 13, 7},
+		{"worker", 0, "MODE", 25, 9},
+		{"worker", 1, "TOKEN", 26, 9},
+	}
+	for _, tt := range tests {
+		variable := project.Services[tt.service].EnvironmentVariables[tt.index]
+		if variable.Name != tt.name {
+			t.Fatalf("%s environment[%d] = %s, want %s", tt.service, tt.index, variable.Name, tt.name)
+		}
+		if variable.Location == nil {
+			t.Fatalf("%s: no location", tt.name)
+		}
+		if variable.Location.Line != tt.line || variable.Location.Column != tt.column {
+			t.Errorf("%s: location = %d:%d, want %d:%d", tt.name, variable.Location.Line, variable.Location.Column, tt.line, tt.column)
+		}
+	}
+}
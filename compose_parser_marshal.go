@@ -0,0 +1,1070 @@
+package compose_parser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Отступ YAML по умолчанию
+const defaultYAMLIndent = 2
+
+// ToYAML сериализует проект в YAML в формате Compose. Сервисы и тома выводятся
+// в порядке ServiceOrder и VolumeOrder, остальные элементы - по имени. Служебные поля
+// (CreatedAt, UpdatedAt, Status, Order, Location, Diagnostics, InheritedFields) не выводятся.
+// Подробнее о правилах сериализации см. YAMLOptions
+func (p *ComposeParser) ToYAML(project *ComposeProjectConfig, options *YAMLOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := p.WriteYAML(&buf, project, options); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteYAML сериализует проект в YAML в формате Compose и записывает результат в writer
+func (p *ComposeParser) WriteYAML(writer io.Writer, project *ComposeProjectConfig, options *YAMLOptions) error {
+	if project == nil {
+		return fmt.Errorf("project is nil")
+	}
+	if options == nil {
+		options = &YAMLOptions{}
+	}
+
+	root, err := p.projectNode(project, options)
+	if err != nil {
+		return err
+	}
+	// Подставленные значения могут содержать "$", который при повторном разборе
+	// начинает подстановку. Без интерполяции значения уже записаны как в файле
+	if !p.disableInterpolate {
+		escapeDollars(root)
+	}
+
+	indent := options.Indent
+	if indent <= 0 {
+		indent = defaultYAMLIndent
+	}
+	encoder := yaml.NewEncoder(writer)
+	encoder.SetIndent(indent)
+	if err := encoder.Encode(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}); err != nil {
+		return fmt.Errorf("failed to encode project: %w", err)
+	}
+	return encoder.Close()
+}
+
+// projectNode создает узел верхнего уровня проекта
+func (p *ComposeParser) projectNode(project *ComposeProjectConfig, options *YAMLOptions) (*yaml.Node, error) {
+	root := newMappingNode()
+	addField(root, "version", stringNode(project.Version))
+	addField(root, "name", stringNode(project.Name))
+
+	services := newMappingNode()
+	for _, name := range orderedKeys(project.Services, project.ServiceOrder) {
+		service, err := p.serviceNode(project.Services[name], options)
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", name, err)
+		}
+		addField(services, name, service)
+	}
+	addField(root, "services", services)
+
+	if len(project.Networks) > 0 {
+		networks := newMappingNode()
+		for _, name := range orderedKeys(project.Networks, nil) {
+			network, err := networkNode(project.Networks[name])
+			if err != nil {
+				return nil, fmt.Errorf("network %s: %w", name, err)
+			}
+			addField(networks, name, network)
+		}
+		addField(root, "networks", networks)
+	}
+
+	if len(project.Volumes) > 0 {
+		volumes := newMappingNode()
+		for _, name := range orderedKeys(project.Volumes, project.VolumeOrder) {
+			volume := project.Volumes[name]
+			node := newMappingNode()
+			if volume != nil {
+				addField(node, "driver", stringNode(volume.Driver))
+				addField(node, "driver_opts", stringMapNode(volume.DriverOpts))
+				addField(node, "external", trueNode(volume.External))
+				addField(node, "name", stringNode(volume.Name))
+				addField(node, "labels", stringMapNode(volume.Labels))
+				if err := addExtensions(node, volume.Extensions, volume.ExtensionNodes); err != nil {
+					return nil, fmt.Errorf("volume %s: %w", name, err)
+				}
+			}
+			addField(volumes, name, orNullNode(node))
+		}
+		addField(root, "volumes", volumes)
+	}
+
+	if len(project.Secrets) > 0 {
+		secrets := newMappingNode()
+		for _, name := range orderedKeys(project.Secrets, nil) {
+			secret := project.Secrets[name]
+			if secret == nil {
+				secret = &SecretConfig{}
+			}
+			node, err := fileResourceNode(secret.File, secret.External, secret.Name, secret.Labels, secret.Extensions, secret.ExtensionNodes)
+			if err != nil {
+				return nil, fmt.Errorf("secret %s: %w", name, err)
+			}
+			addField(secrets, name, node)
+		}
+		addField(root, "secrets", secrets)
+	}
+
+	if len(project.Configs) > 0 {
+		configs := newMappingNode()
+		for _, name := range orderedKeys(project.Configs, nil) {
+			config := project.Configs[name]
+			if config == nil {
+				config = &ConfigConfig{}
+			}
+			node, err := fileResourceNode(config.File, config.External, config.Name, config.Labels, config.Extensions, config.ExtensionNodes)
+			if err != nil {
+				return nil, fmt.Errorf("config %s: %w", name, err)
+			}
+			addField(configs, name, node)
+		}
+		addField(root, "configs", configs)
+	}
+
+	if err := addExtensions(root, project.Extensions, project.ExtensionNodes); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// serviceNode создает узел сервиса. Поле extends не выводится: значения базового
+// сервиса уже включены в сервис при разборе
+func (p *ComposeParser) serviceNode(service *ComposeServiceConfig, options *YAMLOptions) (*yaml.Node, error) {
+	node := newMappingNode()
+	if service == nil {
+		return node, nil
+	}
+
+	// Основные параметры
+	addField(node, "image", stringNode(service.Image))
+	addField(node, "build", buildNode(service.Build, options))
+	addField(node, "container_name", stringNode(service.ContainerName))
+	addField(node, "command", commandNode(service.Command))
+	addField(node, "entrypoint", commandNode(service.Entrypoint))
+	addField(node, "working_dir", stringNode(service.WorkingDir))
+	addField(node, "user", stringNode(service.User))
+	addField(node, "platform", stringNode(service.Platform))
+	addField(node, "profiles", stringListNode(service.Profiles))
+
+	// Зависимости и перезапуск
+	addField(node, "depends_on", dependsOnNode(service.DependsOn, options))
+	addField(node, "restart", stringNode(service.Restart))
+
+	// Сеть и порты
+	addField(node, "ports", p.portsNode(service.Ports, options))
+	addField(node, "expose", stringListNode(service.Expose))
+	addField(node, "networks", serviceNetworksNode(service.Networks, options))
+	addField(node, "network_mode", stringNode(service.NetworkMode))
+	addField(node, "hostname", stringNode(service.Hostname))
+	addField(node, "domainname", stringNode(service.Domainname))
+	addField(node, "dns", stringListNode(service.DNS))
+	addField(node, "dns_search", stringListNode(service.DNSSearch))
+	addField(node, "dns_opt", stringListNode(service.DNSOpt))
+	addField(node, "extra_hosts", extraHostsNode(service.ExtraHosts, options))
+
+	// Переменные окружения
+	addField(node, "environment", environmentNode(service.EnvironmentVariables, service.Environment))
+	addField(node, "env_file", envFileNode(service.EnvFile, options))
+
+	// Тома и монтирования
+	addField(node, "volumes", p.volumeMountsNode(service.Volumes, options))
+	addField(node, "volumes_from", stringListNode(service.VolumesFrom))
+	addField(node, "tmpfs", stringListNode(service.Tmpfs))
+	addField(node, "devices", devicesNode(service.Devices, options))
+
+	// Секреты и конфигурации
+	addField(node, "secrets", fileReferencesNode(service.Secrets, options))
+	addField(node, "configs", fileReferencesNode(service.Configs, options))
+
+	// Ресурсы
+	addField(node, "deploy", deployNode(service.Deploy))
+	addField(node, "cpu_shares", intNode(service.CPUShares))
+	addField(node, "cpuset", stringNode(service.CPUSet))
+	addField(node, "cpu_quota", intNode(service.CPUQuota))
+	addField(node, "cpus", floatNode(service.CPUs))
+	// Ключи memory и memory_swap не входят в спецификацию, записываются ключи спецификации
+	memLimit := service.MemLimit
+	if memLimit == "" {
+		memLimit = service.Memory
+	}
+	addField(node, "mem_limit", stringNode(memLimit))
+	addField(node, "memswap_limit", stringNode(service.MemorySwap))
+	addField(node, "mem_reservation", stringNode(service.MemReservation))
+	addField(node, "shm_size", stringNode(service.ShmSize))
+	addField(node, "oom_score_adj", intNode(service.OomScoreAdj))
+	addField(node, "ulimits", ulimitsNode(service.Ulimits))
+	addField(node, "sysctls", stringMapNode(service.Sysctls))
+
+	// Безопасность и пространства имен
+	addField(node, "privileged", trueNode(service.Privileged))
+	addField(node, "read_only", trueNode(service.ReadOnly))
+	addField(node, "cap_add", stringListNode(service.CapAdd))
+	addField(node, "cap_drop", stringListNode(service.CapDrop))
+	addField(node, "security_opt", stringListNode(service.SecurityOpt))
+	addField(node, "group_add", stringListNode(service.GroupAdd))
+	addField(node, "ipc", stringNode(service.Ipc))
+	addField(node, "pid", stringNode(service.Pid))
+	addField(node, "userns_mode", stringNode(service.UsernsMode))
+
+	// Запуск и остановка
+	addField(node, "init", boolPtrNode(service.Init))
+	addField(node, "tty", trueNode(service.Tty))
+	addField(node, "stdin_open", trueNode(service.StdinOpen))
+	addField(node, "stop_signal", stringNode(service.StopSignal))
+	addField(node, "stop_grace_period", stringNode(service.StopGracePeriod))
+	addField(node, "pull_policy", stringNode(service.PullPolicy))
+	if service.Scale != nil {
+		addField(node, "scale", uintNode(*service.Scale, true))
+	}
+	addField(node, "attach", boolPtrNode(service.Attach))
+	addField(node, "runtime", stringNode(service.Runtime))
+
+	// Логирование и здоровье
+	if service.Logging != nil {
+		logging := newMappingNode()
+		addField(logging, "driver", stringNode(service.Logging.Driver))
+		addField(logging, "options", stringMapNode(service.Logging.Options))
+		addField(node, "logging", logging)
+	}
+	if healthcheck := service.HealthCheck; healthcheck != nil {
+		healthcheckNode := newMappingNode()
+		addField(healthcheckNode, "test", commandNode(healthcheck.Test))
+		addField(healthcheckNode, "interval", stringNode(healthcheck.Interval))
+		addField(healthcheckNode, "timeout", stringNode(healthcheck.Timeout))
+		addField(healthcheckNode, "retries", uintNode(healthcheck.Retries, false))
+		addField(healthcheckNode, "start_period", stringNode(healthcheck.StartPeriod))
+		addField(healthcheckNode, "start_interval", stringNode(healthcheck.StartInterval))
+		addField(node, "healthcheck", healthcheckNode)
+	}
+
+	// Метки и расширения
+	addField(node, "labels", stringMapNode(service.Labels))
+	addField(node, "annotations", stringMapNode(service.Annotations))
+	if err := addExtensions(node, service.Extensions, service.ExtensionNodes); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// buildNode создает узел сборки. Короткая форма используется, если задан только контекст
+func buildNode(build *BuildConfig, options *YAMLOptions) *yaml.Node {
+	if build == nil {
+		return nil
+	}
+	if !options.LongSyntax && reflect.DeepEqual(*build, BuildConfig{Context: build.Context}) {
+		return stringNode(build.Context)
+	}
+
+	node := newMappingNode()
+	addField(node, "context", stringNode(build.Context))
+	addField(node, "dockerfile", stringNode(build.Dockerfile))
+	if dockerfile := stringNode(build.DockerfileInline); dockerfile != nil {
+		if strings.Contains(build.DockerfileInline, "\n") {
+			dockerfile.Style = yaml.LiteralStyle
+		}
+		addField(node, "dockerfile_inline", dockerfile)
+	}
+	addField(node, "args", stringMapNode(build.Args))
+	addField(node, "target", stringNode(build.Target))
+	addField(node, "cache_from", stringListNode(build.CacheFrom))
+	addField(node, "cache_to", stringListNode(build.CacheTo))
+	addField(node, "no_cache", trueNode(build.NoCache))
+	addField(node, "pull", trueNode(build.Pull))
+	addField(node, "labels", stringMapNode(build.Labels))
+	addField(node, "additional_contexts", stringMapNode(build.AdditionalContexts))
+	if len(build.SSH) > 0 {
+		// Пустой путь означает агент SSH по умолчанию и записывается без "="
+		ssh := newSequenceNode()
+		for _, id := range slices.Sorted(maps.Keys(build.SSH)) {
+			entry := id
+			if build.SSH[id] != "" {
+				entry += "=" + build.SSH[id]
+			}
+			ssh.Content = append(ssh.Content, newScalarNode(entry))
+		}
+		addField(node, "ssh", ssh)
+	}
+	addField(node, "secrets", fileReferencesNode(build.Secrets, options))
+	addField(node, "platforms", stringListNode(build.Platforms))
+	addField(node, "tags", stringListNode(build.Tags))
+	addField(node, "network", stringNode(build.Network))
+	addField(node, "shm_size", stringNode(build.ShmSize))
+	addField(node, "extra_hosts", extraHostsNode(build.ExtraHosts, options))
+	addField(node, "isolation", stringNode(build.Isolation))
+	addField(node, "privileged", trueNode(build.Privileged))
+	addField(node, "ulimits", ulimitsNode(build.Ulimits))
+	addField(node, "entitlements", stringListNode(build.Entitlements))
+	return node
+}
+
+// commandNode создает узел команды. Команда из одного элемента записывается строкой,
+// так как при разборе строка и список из одной строки не различаются.
+// Пустой список сохраняется: он сбрасывает значение из образа
+func commandNode(command []string) *yaml.Node {
+	if command == nil {
+		return nil
+	}
+	if len(command) == 1 {
+		return newScalarNode(command[0])
+	}
+	node := newSequenceNode()
+	node.Style = yaml.FlowStyle
+	for _, arg := range command {
+		node.Content = append(node.Content, newScalarNode(arg))
+	}
+	return node
+}
+
+// dependsOnNode создает узел зависимостей. Короткая форма используется, если все
+// зависимости обязательны, ждут запуска сервиса и не перезапускают зависимый сервис
+func dependsOnNode(dependencies []ServiceDependency, options *YAMLOptions) *yaml.Node {
+	if len(dependencies) == 0 {
+		return nil
+	}
+
+	short := !options.LongSyntax
+	for _, dependency := range dependencies {
+		if dependency.Restart || !dependency.Required ||
+			(dependency.Condition != "" && dependency.Condition != DependencyConditionStarted) {
+			short = false
+		}
+	}
+
+	if short {
+		node := newSequenceNode()
+		for _, dependency := range dependencies {
+			node.Content = append(node.Content, newScalarNode(dependency.Service))
+		}
+		return node
+	}
+
+	node := newMappingNode()
+	for _, dependency := range dependencies {
+		condition := dependency.Condition
+		if condition == "" {
+			condition = DependencyConditionStarted
+		}
+		dependencyNode := newMappingNode()
+		addField(dependencyNode, "condition", stringNode(condition))
+		addField(dependencyNode, "restart", trueNode(dependency.Restart))
+		if !dependency.Required {
+			addField(dependencyNode, "required", boolNode(false))
+		}
+		addField(node, dependency.Service, dependencyNode)
+	}
+	return node
+}
+
+// portsNode создает узел портов. Короткая форма используется для портов,
+// которые она передает без потерь
+func (p *ComposeParser) portsNode(ports []PortMapping, options *YAMLOptions) *yaml.Node {
+	if len(ports) == 0 {
+		return nil
+	}
+
+	node := newSequenceNode()
+	for _, port := range ports {
+		if !options.LongSyntax {
+			if spec, ok := p.portShortSyntax(port); ok {
+				node.Content = append(node.Content, newScalarNode(spec))
+				continue
+			}
+		}
+
+		portNode := newMappingNode()
+		addField(portNode, "name", stringNode(port.Name))
+		addField(portNode, "target", uintNode(uint64(port.Target), true))
+		addField(portNode, "host_ip", stringNode(port.HostIP))
+		if port.PublishedEnd != 0 {
+			addField(portNode, "published", stringNode(fmt.Sprintf("%d-%d", port.Published, port.PublishedEnd)))
+		} else {
+			addField(portNode, "published", uintNode(uint64(port.Published), false))
+		}
+		addField(portNode, "protocol", stringNode(port.Protocol))
+		addField(portNode, "app_protocol", stringNode(port.AppProtocol))
+		addField(portNode, "mode", stringNode(port.Mode))
+		node.Content = append(node.Content, portNode)
+	}
+	return node
+}
+
+// portShortSyntax возвращает короткую форму порта
+// [host_ip:][published[-end]:]target[/protocol], если она разбирается в тот же порт
+func (p *ComposeParser) portShortSyntax(port PortMapping) (string, bool) {
+	spec := strconv.Itoa(int(port.Target))
+	if port.Published != 0 || port.HostIP != "" {
+		published := ""
+		if port.Published != 0 {
+			published = strconv.Itoa(int(port.Published))
+		}
+		if port.PublishedEnd != 0 {
+			published += "-" + strconv.Itoa(int(port.PublishedEnd))
+		}
+		spec = published + ":" + spec
+	}
+	if port.HostIP != "" {
+		hostIP := port.HostIP
+		if strings.Contains(hostIP, ":") {
+			hostIP = "[" + hostIP + "]"
+		}
+		spec = hostIP + ":" + spec
+	}
+	if port.Protocol != "" {
+		spec += "/" + port.Protocol
+	}
+
+	port.Location = nil
+	parsed, err := p.parsePortString(spec)
+	return spec, err == nil && reflect.DeepEqual(parsed, []PortMapping{port})
+}
+
+// serviceNetworksNode создает узел подключений сервиса к сетям. Короткая форма
+// (список имен) используется, если подключения не имеют настроек
+func serviceNetworksNode(networks []ServiceNetworkConfig, options *YAMLOptions) *yaml.Node {
+	if len(networks) == 0 {
+		return nil
+	}
+
+	short := !options.LongSyntax
+	for _, network := range networks {
+		if !isBareNetwork(network) {
+			short = false
+		}
+	}
+
+	if short {
+		node := newSequenceNode()
+		for _, network := range networks {
+			node.Content = append(node.Content, newScalarNode(network.Name))
+		}
+		return node
+	}
+
+	node := newMappingNode()
+	for _, network := range networks {
+		networkNode := newMappingNode()
+		addField(networkNode, "aliases", stringListNode(network.Aliases))
+		addField(networkNode, "ipv4_address", stringNode(network.IPv4Address))
+		addField(networkNode, "ipv6_address", stringNode(network.IPv6Address))
+		addField(networkNode, "link_local_ips", stringListNode(network.LinkLocalIPs))
+		addField(networkNode, "mac_address", stringNode(network.MacAddress))
+		addField(networkNode, "driver_opts", stringMapNode(network.DriverOpts))
+		addField(networkNode, "priority", intNode(int64(network.Priority)))
+		addField(networkNode, "gw_priority", intNode(int64(network.GwPriority)))
+		addField(networkNode, "interface_name", stringNode(network.InterfaceName))
+		addField(node, network.Name, orNullNode(networkNode))
+	}
+	return node
+}
+
+// isBareNetwork проверяет, что подключение к сети не имеет настроек
+func isBareNetwork(network ServiceNetworkConfig) bool {
+	return reflect.DeepEqual(network, ServiceNetworkConfig{Name: network.Name, Location: network.Location})
+}
+
+// extraHostsNode создает узел записей /etc/hosts: список "host:ip" в короткой форме
+// или отображение имени хоста на адрес или список адресов в длинной
+func extraHostsNode(hosts map[string][]string, options *YAMLOptions) *yaml.Node {
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	if !options.LongSyntax {
+		node := newSequenceNode()
+		for _, host := range slices.Sorted(maps.Keys(hosts)) {
+			for _, address := range hosts[host] {
+				node.Content = append(node.Content, newScalarNode(host+":"+address))
+			}
+		}
+		return node
+	}
+
+	node := newMappingNode()
+	for _, host := range slices.Sorted(maps.Keys(hosts)) {
+		if addresses := hosts[host]; len(addresses) == 1 {
+			addField(node, host, newScalarNode(addresses[0]))
+		} else {
+			addField(node, host, stringListNode(addresses))
+		}
+	}
+	return node
+}
+
+// environmentNode создает узел переменных окружения. Переменные выводятся из variables
+// в порядке объявления, переменные без значения записываются как null. Если variables
+// не заданы, выводятся значения environment по имени
+func environmentNode(variables []EnvironmentVariable, environment map[string]string) *yaml.Node {
+	if variables == nil {
+		return stringMapNode(environment)
+	}
+	if len(variables) == 0 {
+		return nil
+	}
+
+	node := newMappingNode()
+	for _, variable := range variables {
+		if variable.IsPassThrough() {
+			addField(node, variable.Name, nullNode())
+		} else {
+			addField(node, variable.Name, newScalarNode(*variable.Value))
+		}
+	}
+	return node
+}
+
+// envFileNode создает узел файлов переменных окружения. Короткая форма (путь или список
+// путей) используется, если все файлы обязательны и имеют формат по умолчанию
+func envFileNode(envFiles []EnvFileConfig, options *YAMLOptions) *yaml.Node {
+	if len(envFiles) == 0 {
+		return nil
+	}
+
+	short := !options.LongSyntax
+	for _, envFile := range envFiles {
+		if !envFile.Required || envFile.Format != EnvFileFormatDotEnv {
+			short = false
+		}
+	}
+
+	if short && len(envFiles) == 1 {
+		return newScalarNode(envFiles[0].Path)
+	}
+
+	node := newSequenceNode()
+	for _, envFile := range envFiles {
+		if short {
+			node.Content = append(node.Content, newScalarNode(envFile.Path))
+			continue
+		}
+		envFileNode := newMappingNode()
+		addField(envFileNode, "path", stringNode(envFile.Path))
+		addField(envFileNode, "required", boolNode(envFile.Required))
+		addField(envFileNode, "format", stringNode(envFile.Format))
+		node.Content = append(node.Content, envFileNode)
+	}
+	return node
+}
+
+// volumeMountsNode создает узел монтирований. Короткая форма используется
+// для монтирований, которые она передает без потерь
+func (p *ComposeParser) volumeMountsNode(volumes []VolumeMount, options *YAMLOptions) *yaml.Node {
+	if len(volumes) == 0 {
+		return nil
+	}
+
+	node := newSequenceNode()
+	for _, volume := range volumes {
+		if !options.LongSyntax {
+			if spec, ok := p.volumeShortSyntax(volume); ok {
+				node.Content = append(node.Content, newScalarNode(spec))
+				continue
+			}
+		}
+
+		volumeNode := newMappingNode()
+		addField(volumeNode, "type", stringNode(volume.Type))
+		addField(volumeNode, "source", stringNode(volume.Source))
+		addField(volumeNode, "target", stringNode(volume.Target))
+		addField(volumeNode, "read_only", trueNode(volume.ReadOnly))
+		addField(volumeNode, "consistency", stringNode(volume.Consistency))
+		if bind := volume.Bind; bind != nil {
+			bindNode := newMappingNode()
+			addField(bindNode, "propagation", stringNode(bind.Propagation))
+			addField(bindNode, "create_host_path", trueNode(bind.CreateHostPath))
+			addField(bindNode, "selinux", stringNode(bind.SELinux))
+			addField(volumeNode, "bind", bindNode)
+		}
+		if volumeOptions := volume.Volume; volumeOptions != nil {
+			optionsNode := newMappingNode()
+			addField(optionsNode, "nocopy", trueNode(volumeOptions.NoCopy))
+			addField(optionsNode, "subpath", stringNode(volumeOptions.Subpath))
+			addField(volumeNode, "volume", optionsNode)
+		}
+		if tmpfs := volume.Tmpfs; tmpfs != nil {
+			tmpfsNode := newMappingNode()
+			addField(tmpfsNode, "size", stringNode(tmpfs.Size))
+			if tmpfs.Mode != 0 {
+				addField(tmpfsNode, "mode", fileModeNode(tmpfs.Mode))
+			}
+			addField(volumeNode, "tmpfs", tmpfsNode)
+		}
+		if image := volume.Image; image != nil {
+			imageNode := newMappingNode()
+			addField(imageNode, "subpath", stringNode(image.Subpath))
+			addField(volumeNode, "image", imageNode)
+		}
+		node.Content = append(node.Content, volumeNode)
+	}
+	return node
+}
+
+// volumeShortSyntax возвращает короткую форму монтирования [source:]target[:options],
+// если она разбирается в то же монтирование
+func (p *ComposeParser) volumeShortSyntax(volume VolumeMount) (string, bool) {
+	var options []string
+	if volume.ReadOnly {
+		options = append(options, "ro")
+	}
+	if volume.Consistency != "" {
+		options = append(options, volume.Consistency)
+	}
+	if volume.Volume != nil && volume.Volume.NoCopy {
+		options = append(options, "nocopy")
+	}
+	if volume.Bind != nil {
+		if volume.Bind.SELinux != "" {
+			options = append(options, volume.Bind.SELinux)
+		}
+		if volume.Bind.Propagation != "" {
+			options = append(options, volume.Bind.Propagation)
+		}
+	}
+
+	// Параметры задаются только вместе с источником
+	if volume.Source == "" && len(options) > 0 {
+		return "", false
+	}
+	spec := volume.Target
+	if volume.Source != "" {
+		spec = volume.Source + ":" + spec
+	}
+	if len(options) > 0 {
+		spec += ":" + strings.Join(options, ",")
+	}
+
+	volume.Location = nil
+	parsed, err := p.parseVolumeString(spec)
+	return spec, err == nil && reflect.DeepEqual(*parsed, volume)
+}
+
+// devicesNode создает узел устройств. Короткая форма используется для устройств,
+// которые она передает без потерь
+func devicesNode(devices []DeviceMapping, options *YAMLOptions) *yaml.Node {
+	if len(devices) == 0 {
+		return nil
+	}
+
+	node := newSequenceNode()
+	for _, device := range devices {
+		if !options.LongSyntax {
+			spec := device.Source
+			if device.Target != "" {
+				spec += ":" + device.Target
+			}
+			if device.Permissions != "" {
+				spec += ":" + device.Permissions
+			}
+			if parsed, err := parseDeviceString(spec); err == nil && parsed == device {
+				node.Content = append(node.Content, newScalarNode(spec))
+				continue
+			}
+		}
+
+		deviceNode := newMappingNode()
+		addField(deviceNode, "source", stringNode(device.Source))
+		addField(deviceNode, "target", stringNode(device.Target))
+		addField(deviceNode, "permissions", stringNode(device.Permissions))
+		node.Content = append(node.Content, deviceNode)
+	}
+	return node
+}
+
+// fileReferencesNode создает узел подключения секретов или конфигураций.
+// Короткая форма (имя) используется, если задан только источник
+func fileReferencesNode(references []ServiceFileReference, options *YAMLOptions) *yaml.Node {
+	if len(references) == 0 {
+		return nil
+	}
+
+	node := newSequenceNode()
+	for _, reference := range references {
+		if !options.LongSyntax && reference == (ServiceFileReference{Source: reference.Source}) {
+			node.Content = append(node.Content, newScalarNode(reference.Source))
+			continue
+		}
+
+		referenceNode := newMappingNode()
+		addField(referenceNode, "source", stringNode(reference.Source))
+		addField(referenceNode, "target", stringNode(reference.Target))
+		addField(referenceNode, "uid", stringNode(reference.UID))
+		addField(referenceNode, "gid", stringNode(reference.GID))
+		if reference.Mode != nil {
+			addField(referenceNode, "mode", fileModeNode(*reference.Mode))
+		}
+		node.Content = append(node.Content, referenceNode)
+	}
+	return node
+}
+
+// ulimitsNode создает узел ограничений ресурсов: число для единого значения
+// или отображение soft/hard
+func ulimitsNode(ulimits map[string]*UlimitConfig) *yaml.Node {
+	if len(ulimits) == 0 {
+		return nil
+	}
+
+	node := newMappingNode()
+	for _, name := range slices.Sorted(maps.Keys(ulimits)) {
+		ulimit := ulimits[name]
+		if ulimit == nil {
+			continue
+		}
+		if ulimit.Soft == 0 && ulimit.Hard == 0 {
+			addField(node, name, intValueNode(ulimit.Single))
+			continue
+		}
+		limits := newMappingNode()
+		addField(limits, "soft", intValueNode(ulimit.Soft))
+		addField(limits, "hard", intValueNode(ulimit.Hard))
+		addField(node, name, limits)
+	}
+	return node
+}
+
+// deployNode создает узел конфигурации развертывания
+func deployNode(deploy *DeployConfig) *yaml.Node {
+	if deploy == nil {
+		return nil
+	}
+
+	node := newMappingNode()
+	addField(node, "mode", stringNode(deploy.Mode))
+	addField(node, "replicas", uintNode(deploy.Replicas, false))
+
+	if placement := deploy.Placement; placement != nil {
+		placementNode := newMappingNode()
+		addField(placementNode, "constraints", stringListNode(placement.Constraints))
+		if len(placement.Preferences) > 0 {
+			// Предпочтения хранятся в виде "spread=node.labels.zone"
+			preferences := newSequenceNode()
+			for _, preference := range placement.Preferences {
+				key, value, ok := strings.Cut(preference, "=")
+				if !ok {
+					preferences.Content = append(preferences.Content, newScalarNode(preference))
+					continue
+				}
+				preferenceNode := newMappingNode()
+				addField(preferenceNode, key, newScalarNode(value))
+				preferences.Content = append(preferences.Content, preferenceNode)
+			}
+			addField(placementNode, "preferences", preferences)
+		}
+		addField(placementNode, "max_replicas", uintNode(placement.MaxReplicas, false))
+		addField(node, "placement", placementNode)
+	}
+
+	if resources := deploy.Resources; resources != nil {
+		resourcesNode := newMappingNode()
+		addField(resourcesNode, "limits", resourceLimitsNode(resources.Limits))
+		addField(resourcesNode, "reservations", resourceLimitsNode(resources.Reservations))
+		addField(node, "resources", resourcesNode)
+	}
+
+	if policy := deploy.RestartPolicy; policy != nil {
+		policyNode := newMappingNode()
+		addField(policyNode, "condition", stringNode(policy.Condition))
+		addField(policyNode, "delay", stringNode(policy.Delay))
+		addField(policyNode, "max_attempts", uintNode(policy.MaxAttempts, false))
+		addField(policyNode, "window", stringNode(policy.Window))
+		addField(node, "restart_policy", policyNode)
+	}
+
+	if config := deploy.UpdateConfig; config != nil {
+		addField(node, "update_config", rolloutNode(config.Parallelism, config.Delay, config.FailureAction,
+			config.Monitor, config.MaxFailureRatio, config.Order))
+	}
+	if config := deploy.RollbackConfig; config != nil {
+		addField(node, "rollback_config", rolloutNode(config.Parallelism, config.Delay, config.FailureAction,
+			config.Monitor, config.MaxFailureRatio, config.Order))
+	}
+	return node
+}
+
+// resourceLimitsNode создает узел лимитов ресурсов
+func resourceLimitsNode(limits *ResourceLimits) *yaml.Node {
+	if limits == nil {
+		return nil
+	}
+	node := newMappingNode()
+	addField(node, "cpus", numberNode(limits.CPUs))
+	addField(node, "memory", stringNode(limits.Memory))
+	addField(node, "pids", intNode(limits.Pids))
+	return node
+}
+
+// rolloutNode создает узел конфигурации обновления или отката
+func rolloutNode(parallelism uint64, delay, failureAction, monitor, maxFailureRatio, order string) *yaml.Node {
+	node := newMappingNode()
+	addField(node, "parallelism", uintNode(parallelism, false))
+	addField(node, "delay", stringNode(delay))
+	addField(node, "failure_action", stringNode(failureAction))
+	addField(node, "monitor", stringNode(monitor))
+	addField(node, "max_failure_ratio", numberNode(maxFailureRatio))
+	addField(node, "order", stringNode(order))
+	return node
+}
+
+// networkNode создает узел сети верхнего уровня
+func networkNode(network *NetworkConfig) (*yaml.Node, error) {
+	if network == nil {
+		return nullNode(), nil
+	}
+
+	node := newMappingNode()
+
+	addField(node, "driver", stringNode(network.Driver))
+	addField(node, "driver_opts", stringMapNode(network.DriverOpts))
+	addField(node, "external", trueNode(network.External))
+	addField(node, "name", stringNode(network.Name))
+	addField(node, "attachable", trueNode(network.Attachable))
+	addField(node, "internal", trueNode(network.Internal))
+	addField(node, "enable_ipv4", boolPtrNode(network.EnableIPv4))
+	addField(node, "enable_ipv6", boolPtrNode(network.EnableIPv6))
+	if ipam := network.IPAM; ipam != nil {
+		ipamNode := newMappingNode()
+		addField(ipamNode, "driver", stringNode(ipam.Driver))
+		if len(ipam.Config) > 0 {
+			pools := newSequenceNode()
+			for _, pool := range ipam.Config {
+				poolNode := newMappingNode()
+				addField(poolNode, "subnet", stringNode(pool.Subnet))
+				addField(poolNode, "ip_range", stringNode(pool.IPRange))
+				addField(poolNode, "gateway", stringNode(pool.Gateway))
+				addField(poolNode, "aux_addresses", stringMapNode(pool.AuxAddresses))
+				pools.Content = append(pools.Content, poolNode)
+			}
+			addField(ipamNode, "config", pools)
+		}
+		addField(ipamNode, "options", stringMapNode(ipam.Options))
+		addField(node, "ipam", ipamNode)
+	}
+	addField(node, "labels", stringMapNode(network.Labels))
+	if err := addExtensions(node, network.Extensions, network.ExtensionNodes); err != nil {
+		return nil, err
+	}
+	return orNullNode(node), nil
+}
+
+// fileResourceNode создает узел секрета или конфигурации верхнего уровня
+func fileResourceNode(file string, external bool, name string, labels map[string]string,
+	extensions map[string]interface{}, extensionNodes map[string]*yaml.Node) (*yaml.Node, error) {
+	node := newMappingNode()
+	addField(node, "file", stringNode(file))
+	addField(node, "external", trueNode(external))
+	addField(node, "name", stringNode(name))
+	addField(node, "labels", stringMapNode(labels))
+	if err := addExtensions(node, extensions, extensionNodes); err != nil {
+		return nil, err
+	}
+	return orNullNode(node), nil
+}
+
+// addExtensions добавляет поля расширений x-* по имени. Исходные узлы YAML
+// используются, если они сохранены, иначе кодируются декодированные значения
+func addExtensions(node *yaml.Node, extensions map[string]interface{}, extensionNodes map[string]*yaml.Node) error {
+	keys := slices.Collect(maps.Keys(extensions))
+	for key := range extensionNodes {
+		if _, ok := extensions[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		if value, ok := extensionNodes[key]; ok && value != nil {
+			addField(node, key, expandNode(value))
+			continue
+		}
+		value := &yaml.Node{}
+		if err := value.Encode(extensions[key]); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", key, err)
+		}
+		addField(node, key, value)
+	}
+	return nil
+}
+
+// orderedKeys возвращает ключи в заданном порядке, затем остальные ключи по имени.
+// Ключи порядка, отсутствующие в items, пропускаются
+func orderedKeys[T any](items map[string]T, order []string) []string {
+	keys := make([]string, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for _, key := range order {
+		if _, ok := items[key]; ok && !seen[key] {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(items)) {
+		if !seen[key] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// escapeDollars заменяет "$" на "$$" в строковых значениях дерева, ключи не изменяются
+func escapeDollars(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			escapeDollars(node.Content[i])
+		}
+	case yaml.SequenceNode, yaml.DocumentNode:
+		for _, child := range node.Content {
+			escapeDollars(child)
+		}
+	case yaml.ScalarNode:
+		if node.ShortTag() == "!!str" {
+			node.Value = strings.ReplaceAll(node.Value, "$", "$$")
+		}
+	}
+}
+
+// addField добавляет ключ в отображение, если значение задано
+func addField(node *yaml.Node, key string, value *yaml.Node) {
+	if value != nil {
+		node.Content = append(node.Content, newScalarNode(key), value)
+	}
+}
+
+// newMappingNode создает пустое отображение
+func newMappingNode() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
+
+// newSequenceNode создает пустой список
+func newSequenceNode() *yaml.Node {
+	return &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+}
+
+// nullNode создает пустое значение
+func nullNode() *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+}
+
+// orNullNode заменяет пустое отображение пустым значением: "name:" вместо "name: {}"
+func orNullNode(node *yaml.Node) *yaml.Node {
+	if len(node.Content) == 0 {
+		return nullNode()
+	}
+	return node
+}
+
+// stringNode создает строковый узел, для пустой строки возвращает nil
+func stringNode(value string) *yaml.Node {
+	if value == "" {
+		return nil
+	}
+	return newScalarNode(value)
+}
+
+// stringListNode создает список строк, для пустого списка возвращает nil
+func stringListNode(values []string) *yaml.Node {
+	if len(values) == 0 {
+		return nil
+	}
+	node := newSequenceNode()
+	for _, value := range values {
+		node.Content = append(node.Content, newScalarNode(value))
+	}
+	return node
+}
+
+// stringMapNode создает отображение строк с ключами по имени, для пустого отображения возвращает nil
+func stringMapNode(values map[string]string) *yaml.Node {
+	if len(values) == 0 {
+		return nil
+	}
+	node := newMappingNode()
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		addField(node, key, newScalarNode(values[key]))
+	}
+	return node
+}
+
+// boolNode создает логический узел
+func boolNode(value bool) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(value)}
+}
+
+// trueNode создает логический узел для значения true, для false возвращает nil
+func trueNode(value bool) *yaml.Node {
+	if !value {
+		return nil
+	}
+	return boolNode(true)
+}
+
+// boolPtrNode создает логический узел для заданного значения
+func boolPtrNode(value *bool) *yaml.Node {
+	if value == nil {
+		return nil
+	}
+	return boolNode(*value)
+}
+
+// intValueNode создает целочисленный узел
+func intValueNode(value int64) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(value, 10)}
+}
+
+// intNode создает целочисленный узел, для нуля возвращает nil
+func intNode(value int64) *yaml.Node {
+	if value == 0 {
+		return nil
+	}
+	return intValueNode(value)
+}
+
+// uintNode создает целочисленный узел. Ноль выводится, только если keepZero
+func uintNode(value uint64, keepZero bool) *yaml.Node {
+	if value == 0 && !keepZero {
+		return nil
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatUint(value, 10)}
+}
+
+// floatNode создает числовой узел, для нуля возвращает nil
+func floatNode(value float64) *yaml.Node {
+	if value == 0 {
+		return nil
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: strconv.FormatFloat(value, 'f', -1, 64)}
+}
+
+// numberNode создает узел числа, сохраненного строкой (cpus: 0.5). Значение без тега
+// записывается как есть, для пустой строки возвращает nil
+func numberNode(value string) *yaml.Node {
+	if value == "" {
+		return nil
+	}
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		return newScalarNode(value)
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+}
+
+// fileModeNode создает узел прав доступа в восьмеричной записи: 0440
+func fileModeNode(mode uint32) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: fmt.Sprintf("0%o", mode)}
+}
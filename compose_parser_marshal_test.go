@@ -0,0 +1,259 @@
+package compose_parser
+
+import (
+	"strings"
+	"testing"
+)
+
+// roundTripDocument использует большинство сериализуемых элементов проекта
+const roundTripDocument = `name: roundtrip
+services:
+  web:
+    image: nginx:1.25
+    build:
+      context: ./web
+      dockerfile: Dockerfile.dev
+      args:
+        VERSION: "1.0"
+      target: dev
+    command: ["nginx", "-g", "daemon off;"]
+    depends_on:
+      db:
+        condition: service_healthy
+        restart: true
+    restart: unless-stopped
+    ports:
+      - "8080:80"
+      - "127.0.0.1:9000-9001:9000-9001/udp"
+      - target: 443
+        published: "8443"
+        protocol: tcp
+        app_protocol: https
+        name: tls
+    expose:
+      - "3000"
+    networks:
+      front:
+        aliases:
+          - www
+        ipv4_address: 172.28.0.10
+        gw_priority: 1
+      back: {}
+    extra_hosts:
+      - "host.docker.internal:host-gateway"
+    environment:
+      MODE: production
+      EMPTY: ""
+      PASSTHROUGH:
+    volumes:
+      - data:/var/lib/data:ro
+      - ./conf:/etc/nginx/conf.d
+      - type: tmpfs
+        target: /tmp
+        tmpfs:
+          size: 1024
+          mode: 1777
+    secrets:
+      - source: token
+        target: /run/secrets/api_token
+        mode: 0440
+    configs:
+      - app_config
+    mem_limit: 512m
+    memswap_limit: 1g
+    ulimits:
+      nofile:
+        soft: 1024
+        hard: 2048
+    healthcheck:
+      test: ["CMD", "curl", "-f", "http://localhost"]
+      interval: 30s
+      retries: 3
+    labels:
+      com.example.tier: frontend
+    x-custom: value
+  db:
+    image: postgres:16
+    profiles:
+      - backend
+    deploy:
+      replicas: 2
+      resources:
+        limits:
+          cpus: "0.5"
+          memory: 256M
+    networks:
+      - back
+networks:
+  front:
+    driver: bridge
+    enable_ipv4: true
+    enable_ipv6: true
+    ipam:
+      config:
+        - subnet: 172.28.0.0/16
+          gateway: 172.28.0.1
+  back:
+    internal: true
+volumes:
+  data:
+    driver: local
+secrets:
+  token:
+    file: ./token.txt
+configs:
+  app_config:
+    file: ./app.conf
+`
+
+func TestToYAMLRoundTrip(t *testing.T) {
+	for _, options := range []*YAMLOptions{nil, {LongSyntax: true}} {
+		name := "short syntax"
+		if options != nil {
+			name = "long syntax"
+		}
+		t.Run(name, func(t *testing.T) {
+			parser := NewComposeParser(WithEnvironment(map[string]string{}))
+			project, err := parser.ParseYAML([]byte(roundTripDocument))
+			if err != nil {
+				t.Fatalf("ParseYAML: %v", err)
+			}
+
+			data, err := parser.ToYAML(project, options)
+			if err != nil {
+				t.Fatalf("ToYAML: %v", err)
+			}
+
+			diagnostics, err := parser.ValidateYAML(data)
+			if err != nil {
+				t.Fatalf("ValidateYAML: %v", err)
+			}
+			for _, diagnostic := range diagnostics {
+				t.Errorf("schema violation in serialized project: %s\n%s", diagnostic, data)
+			}
+
+			strict := NewComposeParser(WithEnvironment(map[string]string{}), WithStrict())
+			reparsed, err := strict.ParseYAML(data)
+			if err != nil {
+				t.Fatalf("ParseYAML with WithStrict: %v\n%s", err, data)
+			}
+
+			again, err := strict.ToYAML(reparsed, options)
+			if err != nil {
+				t.Fatalf("ToYAML of reparsed project: %v", err)
+			}
+			if string(again) != string(data) {
+				t.Errorf("serialization is not stable:\nfirst:\n%s\nsecond:\n%s", data, again)
+			}
+		})
+	}
+}
+
+func TestToYAMLMemoryKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		service ComposeServiceConfig
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "legacy memory fields",
+			service: ComposeServiceConfig{Image: "app", Memory: "512m", MemorySwap: "1g"},
+			want:    []string{"mem_limit: 512m", "memswap_limit: 1g"},
+			notWant: []string{"memory:", "memory_swap:"},
+		},
+		{
+			name:    "mem_limit takes precedence",
+			service: ComposeServiceConfig{Image: "app", Memory: "512m", MemLimit: "256m"},
+			want:    []string{"mem_limit: 256m"},
+			notWant: []string{"memory:", "512m"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewComposeParser()
+			service := tt.service
+			project := &ComposeProjectConfig{
+				Name:     "memory",
+				Services: map[string]*ComposeServiceConfig{"app": &service},
+			}
+
+			data, err := parser.ToYAML(project, nil)
+			if err != nil {
+				t.Fatalf("ToYAML: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("output does not contain %q:\n%s", want, data)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(string(data), notWant) {
+					t.Errorf("output contains %q:\n%s", notWant, data)
+				}
+			}
+
+			diagnostics, err := parser.ValidateYAML(data)
+			if err != nil {
+				t.Fatalf("ValidateYAML: %v", err)
+			}
+			for _, diagnostic := range diagnostics {
+				t.Errorf("schema violation: %s", diagnostic)
+			}
+		})
+	}
+}
+
+func TestPortShortSyntax(t *testing.T) {
+	tests := []struct {
+		port      PortMapping
+		want      string
+		wantShort bool
+	}{
+		{PortMapping{Target: 80}, "80", true},
+		{PortMapping{Target: 80, Published: 8080}, "8080:80", true},
+		{PortMapping{Target: 53, Published: 53, Protocol: "udp"}, "53:53/udp", true},
+		{PortMapping{Target: 80, Published: 8080, HostIP: "127.0.0.1"}, "127.0.0.1:8080:80", true},
+		{PortMapping{Target: 80, HostIP: "::1"}, "[::1]::80", true},
+		{PortMapping{Target: 80, Published: 8000, PublishedEnd: 8010}, "8000-8010:80", true},
+		{PortMapping{Target: 80, Published: 8080, AppProtocol: "http"}, "8080:80", false},
+		{PortMapping{Target: 80, Mode: "host"}, "80", false},
+		{PortMapping{Target: 80, Name: "web"}, "80", false},
+	}
+
+	parser := NewComposeParser()
+	for _, tt := range tests {
+		got, short := parser.portShortSyntax(tt.port)
+		if got != tt.want || short != tt.wantShort {
+			t.Errorf("portShortSyntax(%+v) = %q, %v, want %q, %v", tt.port, got, short, tt.want, tt.wantShort)
+		}
+	}
+}
+
+func TestVolumeShortSyntax(t *testing.T) {
+	tests := []struct {
+		volume    VolumeMount
+		want      string
+		wantShort bool
+	}{
+		{VolumeMount{Type: "volume", Target: "/data"}, "/data", true},
+		{VolumeMount{Type: "volume", Source: "data", Target: "/data", ReadOnly: true}, "data:/data:ro", true},
+		{VolumeMount{Type: "volume", Source: "data", Target: "/data", Volume: &VolumeVolumeOptions{NoCopy: true}}, "data:/data:nocopy", true},
+		{VolumeMount{Type: "bind", Source: "./conf", Target: "/conf", Bind: &VolumeBindOptions{CreateHostPath: true, SELinux: "z"}}, "./conf:/conf:z", true},
+		{VolumeMount{Type: "bind", Source: `C:\data`, Target: "/data", Bind: &VolumeBindOptions{CreateHostPath: true}}, `C:\data:/data`, true},
+		// Короткая форма всегда создает директорию хоста
+		{VolumeMount{Type: "bind", Source: "./conf", Target: "/conf"}, "./conf:/conf", false},
+		{VolumeMount{Type: "volume", Source: "data", Target: "/data", Volume: &VolumeVolumeOptions{Subpath: "sub"}}, "data:/data", false},
+		{VolumeMount{Type: "tmpfs", Target: "/tmp"}, "/tmp", false},
+		{VolumeMount{Type: "volume", Target: "/data", ReadOnly: true}, "", false},
+	}
+
+	parser := NewComposeParser()
+	for _, tt := range tests {
+		got, short := parser.volumeShortSyntax(tt.volume)
+		if got != tt.want || short != tt.wantShort {
+			t.Errorf("volumeShortSyntax(%+v) = %q, %v, want %q, %v", tt.volume, got, short, tt.want, tt.wantShort)
+		}
+	}
+}
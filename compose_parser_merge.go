@@ -0,0 +1,454 @@
+package compose_parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// resetTag сбрасывает значение атрибута при слиянии файлов
+	resetTag = "!reset"
+	// overrideTag заменяет значение атрибута целиком вместо слияния
+	overrideTag = "!override"
+)
+
+// mergeRule определяет способ слияния значения по пути в документе
+type mergeRule int
+
+const (
+	mergeAppend   mergeRule = iota // Последовательности объединяются
+	mergeReplace                   // Значение из переопределяющего файла заменяет исходное
+	mergeUnique                    // Последовательности объединяются без повторов
+	mergeByKey                     // Элементы последовательности сопоставляются по ключу
+	mergeMapping                   // Список "KEY=VALUE" или отображение сливается по ключу
+	mergeNameList                  // Список имен или отображение имен сливается по имени
+)
+
+// mergeRules задает правила слияния для путей документа, "*" соответствует любому ключу
+var mergeRules = map[string]mergeRule{
+	"services.*.command":                      mergeReplace,
+	"services.*.entrypoint":                   mergeReplace,
+	"services.*.healthcheck.test":             mergeReplace,
+	"services.*.ports":                        mergeByKey,
+	"services.*.volumes":                      mergeByKey,
+	"services.*.secrets":                      mergeByKey,
+	"services.*.configs":                      mergeByKey,
+	"services.*.expose":                       mergeUnique,
+	"services.*.env_file":                     mergeUnique,
+	"services.*.volumes_from":                 mergeUnique,
+	"services.*.dns":                          mergeUnique,
+	"services.*.dns_search":                   mergeUnique,
+	"services.*.dns_opt":                      mergeUnique,
+	"services.*.cap_add":                      mergeUnique,
+	"services.*.cap_drop":                     mergeUnique,
+	"services.*.security_opt":                 mergeUnique,
+	"services.*.group_add":                    mergeUnique,
+	"services.*.profiles":                     mergeUnique,
+	"services.*.links":                        mergeUnique,
+	"services.*.external_links":               mergeUnique,
+	"services.*.tmpfs":                        mergeUnique,
+	"services.*.build.cache_from":             mergeUnique,
+	"services.*.build.cache_to":               mergeUnique,
+	"services.*.build.platforms":              mergeUnique,
+	"services.*.build.tags":                   mergeUnique,
+	"services.*.build.entitlements":           mergeUnique,
+	"services.*.build.secrets":                mergeByKey,
+	"services.*.environment":                  mergeMapping,
+	"services.*.labels":                       mergeMapping,
+	"services.*.annotations":                  mergeMapping,
+	"services.*.sysctls":                      mergeMapping,
+	"services.*.extra_hosts":                  mergeMapping,
+	"services.*.build.args":                   mergeMapping,
+	"services.*.build.labels":                 mergeMapping,
+	"services.*.build.additional_contexts":    mergeMapping,
+	"services.*.build.ssh":                    mergeMapping,
+	"services.*.build.extra_hosts":            mergeMapping,
+	"services.*.logging.options":              mergeMapping,
+	"services.*.depends_on":                   mergeNameList,
+	"services.*.networks":                     mergeNameList,
+	"services.*.deploy.placement.constraints": mergeUnique,
+	"networks.*.labels":                       mergeMapping,
+	"networks.*.driver_opts":                  mergeMapping,
+	"volumes.*.labels":                        mergeMapping,
+	"volumes.*.driver_opts":                   mergeMapping,
+	"secrets.*.labels":                        mergeMapping,
+	"configs.*.labels":                        mergeMapping,
+}
+
+// ParseFiles парсит несколько Docker Compose файлов и объединяет их в один проект
+// по правилам переопределения Compose. Имя проекта определяется по переменной
+// COMPOSE_PROJECT_NAME, ключу name последнего задавшего его файла или директории первого файла
+func (p *ComposeParser) ParseFiles(paths ...string) (*ComposeProjectConfig, error) {
+	return p.ParseFilesWithName("", paths...)
+}
+
+// ParseFilesWithName парсит и объединяет несколько Docker Compose файлов с указанным именем проекта
+func (p *ComposeParser) ParseFilesWithName(projectName string, paths ...string) (*ComposeProjectConfig, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no compose files specified")
+	}
+
+	ctx, projectName, err := p.fileProjectContext(projectName, paths)
+	if err != nil {
+		return nil, err
+	}
+	var merged *yaml.Node
+	for _, filePath := range paths {
+		rootNode, err := p.loadFile(ctx, filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if merged == nil {
+			merged = rootNode
+			continue
+		}
+
+		absPath, err := filepath.Abs(filePath)
+		if err != nil {
+			return nil, err
+		}
+		rebaseOverridePaths(rootNode, filepath.Dir(absPath), ctx.workingDir)
+		merged = p.mergeNodes(merged, rootNode, nil)
+	}
+
+	return p.parseProject(ctx, merged, projectName)
+}
+
+// rebaseOverridePaths пересчитывает пути env_file файла переопределения из его директории
+// в директорию проекта, чтобы после слияния они разрешались от файла, в котором объявлены
+func rebaseOverridePaths(rootNode *yaml.Node, fromDir, toDir string) {
+	servicesNode := mappingValue(rootNode, "services")
+	if servicesNode == nil {
+		return
+	}
+	for i := 1; i < len(servicesNode.Content); i += 2 {
+		rebaseEnvFilePaths(servicesNode.Content[i], fromDir, toDir)
+	}
+}
+
+// loadFile читает Docker Compose файл, разрешает extends и возвращает корневой узел
+func (p *ComposeParser) loadFile(ctx *parseContext, filePath string) (*yaml.Node, error) {
+	return p.loadFileWithLookup(ctx, filePath, ctx.lookup)
+}
+
+// loadFileWithLookup читает Docker Compose файл с указанным источником переменных,
+// подключает include, разрешает extends и возвращает корневой узел
+func (p *ComposeParser) loadFileWithLookup(ctx *parseContext, filePath string, lookup LookupFunc) (*yaml.Node, error) {
+	rootNode, err := p.readFile(ctx, filePath, lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.resolveIncludes(ctx, rootNode, filePath); err != nil {
+		return nil, fmt.Errorf("failed to load file %s: %w", filePath, ctx.completeError(err, ""))
+	}
+	if err := p.resolveExtends(ctx, rootNode, filePath); err != nil {
+		return nil, fmt.Errorf("failed to load file %s: %w", filePath, ctx.completeError(err, ""))
+	}
+	return rootNode, nil
+}
+
+// readFile читает Docker Compose файл и возвращает его корневой узел.
+// Узлы документа регистрируются в контексте для определения их положения
+func (p *ComposeParser) readFile(ctx *parseContext, filePath string, lookup LookupFunc) (*yaml.Node, error) {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if ext != ".yaml" && ext != ".yml" {
+		return nil, fmt.Errorf("unsupported file extension: %s, expected .yaml or .yml", ext)
+	}
+
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
+	}
+
+	rootNode, err := p.loadDocument(ctx, data, lookup, absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load file %s: %w", filePath, ctx.completeError(err, absPath))
+	}
+	ctx.registerSource(rootNode, absPath)
+
+	return rootNode, nil
+}
+
+// mergeNodes сливает override в base и возвращает результат. Узел base может изменяться
+func (p *ComposeParser) mergeNodes(base, override *yaml.Node, path []string) *yaml.Node {
+	if override.Tag == overrideTag || override.Tag == resetTag {
+		return override
+	}
+	if base == nil {
+		return override
+	}
+
+	switch matchMergeRule(path) {
+	case mergeReplace:
+		return override
+	case mergeUnique:
+		base, override = toSequence(base), toSequence(override)
+		if base.Kind == yaml.SequenceNode && override.Kind == yaml.SequenceNode {
+			return mergeSequenceByKey(base, override, scalarKey)
+		}
+	case mergeByKey:
+		if base.Kind == yaml.SequenceNode && override.Kind == yaml.SequenceNode {
+			return mergeSequenceByKey(base, override, sequenceItemKey(path[len(path)-1]))
+		}
+	case mergeMapping:
+		if base.Kind != override.Kind {
+			return mergeSequenceByKey(toKeyValueSequence(base), toKeyValueSequence(override), keyValueKey)
+		}
+		if base.Kind == yaml.SequenceNode {
+			return mergeSequenceByKey(base, override, keyValueKey)
+		}
+	case mergeNameList:
+		if base.Kind == yaml.SequenceNode && override.Kind == yaml.SequenceNode {
+			return mergeSequenceByKey(base, override, scalarKey)
+		}
+		if base.Kind != override.Kind {
+			base, override = toNameMapping(base), toNameMapping(override)
+		}
+	}
+
+	// Короткая форма build (строка контекста) приводится к полной
+	if len(path) == 3 && path[0] == "services" && path[2] == "build" {
+		base, override = toBuildMapping(base), toBuildMapping(override)
+	}
+
+	if base.Kind == yaml.MappingNode && override.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(override.Content); i += 2 {
+			key := override.Content[i].Value
+			childPath := append(append([]string{}, path...), key)
+			existing := mappingValue(base, key)
+			if existing == nil {
+				base.Content = append(base.Content, override.Content[i], override.Content[i+1])
+				continue
+			}
+			setMappingValue(base, key, p.mergeNodes(existing, override.Content[i+1], childPath))
+		}
+		return base
+	}
+
+	if base.Kind == yaml.SequenceNode && override.Kind == yaml.SequenceNode {
+		base.Content = append(base.Content, override.Content...)
+		return base
+	}
+
+	return override
+}
+
+// applyMergeTags применяет теги !reset и !override после слияния:
+// сброшенные ключи удаляются, а теги заменяются стандартными
+func (p *ComposeParser) applyMergeTags(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		content := node.Content[:0]
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i+1].Tag == resetTag {
+				continue
+			}
+			content = append(content, node.Content[i], node.Content[i+1])
+		}
+		node.Content = content
+	case yaml.SequenceNode:
+		content := node.Content[:0]
+		for _, child := range node.Content {
+			if child.Tag != resetTag {
+				content = append(content, child)
+			}
+		}
+		node.Content = content
+	}
+
+	if node.Tag == overrideTag {
+		node.Tag = ""
+	}
+	for _, child := range node.Content {
+		p.applyMergeTags(child)
+	}
+}
+
+// matchMergeRule ищет правило слияния для пути
+func matchMergeRule(path []string) mergeRule {
+	for pattern, rule := range mergeRules {
+		parts := strings.Split(pattern, ".")
+		if len(parts) != len(path) {
+			continue
+		}
+		matched := true
+		for i, part := range parts {
+			if part != "*" && part != path[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return rule
+		}
+	}
+	return mergeAppend
+}
+
+// mergeSequenceByKey объединяет последовательности: элементы override с тем же ключом
+// заменяют элементы base на их месте, новые элементы добавляются в конец
+func mergeSequenceByKey(base, override *yaml.Node, keyFn func(*yaml.Node) string) *yaml.Node {
+	index := make(map[string]int)
+	for i, item := range base.Content {
+		index[keyFn(item)] = i
+	}
+
+	for _, item := range override.Content {
+		key := keyFn(item)
+		if i, exists := index[key]; exists {
+			base.Content[i] = item
+			continue
+		}
+		index[key] = len(base.Content)
+		base.Content = append(base.Content, item)
+	}
+	return base
+}
+
+// scalarKey возвращает ключ элемента для объединения без повторов
+func scalarKey(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+	out, _ := yaml.Marshal(node)
+	return string(out)
+}
+
+// keyValueKey возвращает имя переменной из элемента вида "KEY=VALUE"
+func keyValueKey(node *yaml.Node) string {
+	return strings.SplitN(node.Value, "=", 2)[0]
+}
+
+// sequenceItemKey возвращает функцию, вычисляющую уникальный ключ элемента
+// ports, volumes, secrets или configs
+func sequenceItemKey(field string) func(*yaml.Node) string {
+	return func(node *yaml.Node) string {
+		switch field {
+		case "ports":
+			return portMergeKey(node)
+		case "volumes":
+			if node.Kind == yaml.MappingNode {
+				return mappingScalar(node, "target")
+			}
+			// Разбор как в короткой форме монтирования, буква диска Windows не разделяется
+			parts := splitVolumeSpec(node.Value)
+			if len(parts) == 1 {
+				return parts[0]
+			}
+			return parts[1]
+		default:
+			// secrets и configs сопоставляются по целевому пути или имени источника
+			if node.Kind == yaml.MappingNode {
+				if target := mappingScalar(node, "target"); target != "" {
+					return target
+				}
+				return mappingScalar(node, "source")
+			}
+			return node.Value
+		}
+	}
+}
+
+// portMergeKey вычисляет ключ порта из IP, опубликованного и целевого порта и протокола
+func portMergeKey(node *yaml.Node) string {
+	if node.Kind == yaml.MappingNode {
+		protocol := mappingScalar(node, "protocol")
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		return strings.Join([]string{
+			mappingScalar(node, "host_ip"),
+			mappingScalar(node, "published"),
+			mappingScalar(node, "target"),
+			protocol,
+		}, "|")
+	}
+
+	spec, protocol := node.Value, "tcp"
+	if idx := strings.LastIndex(spec, "/"); idx >= 0 {
+		spec, protocol = spec[:idx], spec[idx+1:]
+	}
+
+	var hostIP, published string
+	target := spec
+	if idx := strings.LastIndex(spec, ":"); idx >= 0 {
+		target = spec[idx+1:]
+		published = spec[:idx]
+		if idx := strings.LastIndex(published, ":"); idx >= 0 {
+			// IPv6 адрес в короткой форме заключен в скобки, в длинной форме - нет
+			hostIP, published = strings.Trim(published[:idx], "[]"), published[idx+1:]
+		}
+	}
+	return strings.Join([]string{hostIP, published, target, protocol}, "|")
+}
+
+// mappingScalar возвращает строковое значение ключа отображения
+func mappingScalar(node *yaml.Node, key string) string {
+	if value := mappingValue(node, key); value != nil && value.Kind == yaml.ScalarNode {
+		return value.Value
+	}
+	return ""
+}
+
+// toSequence приводит скалярное значение к списку из одного элемента
+func toSequence(node *yaml.Node) *yaml.Node {
+	if node.Kind != yaml.ScalarNode || node.Tag == "!!null" {
+		return node
+	}
+	return &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: node.Line, Column: node.Column, Content: []*yaml.Node{node}}
+}
+
+// toKeyValueSequence приводит отображение к списку "KEY=VALUE"
+func toKeyValueSequence(node *yaml.Node) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return node
+	}
+	sequence := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: node.Line, Column: node.Column}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		item := newScalarNode(key.Value)
+		if value.Kind == yaml.ScalarNode && value.Tag != "!!null" {
+			item.Value = key.Value + "=" + value.Value
+		}
+		item.Line, item.Column = key.Line, key.Column
+		sequence.Content = append(sequence.Content, item)
+	}
+	return sequence
+}
+
+// toNameMapping приводит список имен к отображению имен
+func toNameMapping(node *yaml.Node) *yaml.Node {
+	if node.Kind != yaml.SequenceNode {
+		return node
+	}
+	mapping := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: node.Line, Column: node.Column}
+	for _, item := range node.Content {
+		mapping.Content = append(mapping.Content, item, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+	}
+	return mapping
+}
+
+// toBuildMapping приводит короткую форму build к отображению с ключом context
+func toBuildMapping(node *yaml.Node) *yaml.Node {
+	if node == nil || node.Kind != yaml.ScalarNode || node.Tag == resetTag {
+		return node
+	}
+	return &yaml.Node{
+		Kind:    yaml.MappingNode,
+		Tag:     "!!map",
+		Line:    node.Line,
+		Column:  node.Column,
+		Content: []*yaml.Node{newScalarNode("context"), node},
+	}
+}
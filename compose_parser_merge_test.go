@@ -0,0 +1,255 @@
+package compose_parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// writeTestFile создает файл с содержимым в директории теста и возвращает его путь
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// parseTestFiles записывает файлы проекта и объединяет их через ParseFiles
+func parseTestFiles(t *testing.T, files ...string) *ComposeProjectConfig {
+	t.Helper()
+	dir := t.TempDir()
+	var paths []string
+	for i, content := range files {
+		paths = append(paths, writeTestFile(t, dir, []string{"compose.yaml", "override.yaml", "prod.yaml"}[i], content))
+	}
+
+	project, err := NewComposeParser(WithEnvironment(map[string]string{})).ParseFiles(paths...)
+	if err != nil {
+		t.Fatalf("ParseFiles: %v", err)
+	}
+	return project
+}
+
+func TestParseFilesMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		override string
+		check    func(t *testing.T, web *ComposeServiceConfig)
+	}{
+		{
+			name:     "scalars replace",
+			base:     "services:\n  web:\n    image: nginx:1\n    restart: always\n",
+			override: "services:\n  web:\n    image: nginx:2\n",
+			check: func(t *testing.T, web *ComposeServiceConfig) {
+				if web.Image != "nginx:2" || web.Restart != "always" {
+					t.Errorf("image = %q, restart = %q", web.Image, web.Restart)
+				}
+			},
+		},
+		{
+			name:     "command replaces",
+			base:     "services:\n  web:\n    image: app\n    command: [run, --debug]\n",
+			override: "services:\n  web:\n    command: [serve]\n",
+			check: func(t *testing.T, web *ComposeServiceConfig) {
+				if !reflect.DeepEqual(web.Command, []string{"serve"}) {
+					t.Errorf("command = %v", web.Command)
+				}
+			},
+		},
+		{
+			name:     "environment merges by name across forms",
+			base:     "services:\n  web:\n    image: app\n    environment:\n      - A=1\n      - B=2\n",
+			override: "services:\n  web:\n    environment:\n      B: 3\n      C: 4\n",
+			check: func(t *testing.T, web *ComposeServiceConfig) {
+				want := map[string]string{"A": "1", "B": "3", "C": "4"}
+				if !reflect.DeepEqual(web.Environment, want) {
+					t.Errorf("environment = %v, want %v", web.Environment, want)
+				}
+			},
+		},
+		{
+			name:     "ports merge by key",
+			base:     "services:\n  web:\n    image: app\n    ports:\n      - \"8080:80\"\n      - \"443:443\"\n",
+			override: "services:\n  web:\n    ports:\n      - target: 80\n        published: 8080\n        app_protocol: http\n      - \"9000:9000\"\n",
+			check: func(t *testing.T, web *ComposeServiceConfig) {
+				if len(web.Ports) != 3 {
+					t.Fatalf("ports = %+v, want 3 ports", web.Ports)
+				}
+				if web.Ports[0].AppProtocol != "http" || web.Ports[2].Target != 9000 {
+					t.Errorf("ports = %+v", web.Ports)
+				}
+			},
+		},
+		{
+			name:     "volumes merge by target",
+			base:     "services:\n  web:\n    image: app\n    volumes:\n      - ./data:/data\n      - logs:/var/log\n",
+			override: "services:\n  web:\n    volumes:\n      - type: volume\n        source: data\n        target: /data\n      - cache:/cache\n",
+			check: func(t *testing.T, web *ComposeServiceConfig) {
+				var got []string
+				for _, volume := range web.Volumes {
+					got = append(got, volume.Type+":"+volume.Source+":"+volume.Target)
+				}
+				want := []string{"volume:data:/data", "volume:logs:/var/log", "volume:cache:/cache"}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("volumes = %v, want %v", got, want)
+				}
+			},
+		},
+		{
+			name:     "windows volume merges by target",
+			base:     "services:\n  web:\n    image: app\n    volumes:\n      - C:\\data:/data\n",
+			override: "services:\n  web:\n    volumes:\n      - D:\\data:/data:ro\n",
+			check: func(t *testing.T, web *ComposeServiceConfig) {
+				if len(web.Volumes) != 1 || web.Volumes[0].Source != "D:\\data" || !web.Volumes[0].ReadOnly {
+					t.Errorf("volumes = %+v", web.Volumes)
+				}
+			},
+		},
+		{
+			name:     "expose merges without duplicates",
+			base:     "services:\n  web:\n    image: app\n    expose: [\"80\", \"443\"]\n",
+			override: "services:\n  web:\n    expose: [\"443\", \"8080\"]\n",
+			check: func(t *testing.T, web *ComposeServiceConfig) {
+				if want := []string{"80", "443", "8080"}; !reflect.DeepEqual(web.Expose, want) {
+					t.Errorf("expose = %v, want %v", web.Expose, want)
+				}
+			},
+		},
+		{
+			name:     "depends_on merges list and mapping",
+			base:     "services:\n  web:\n    image: app\n    depends_on: [db]\n  db:\n    image: db\n  cache:\n    image: cache\n",
+			override: "services:\n  web:\n    depends_on:\n      cache:\n        condition: service_started\n",
+			check: func(t *testing.T, web *ComposeServiceConfig) {
+				var got []string
+				for _, dependency := range web.DependsOn {
+					got = append(got, dependency.Service)
+				}
+				if want := []string{"db", "cache"}; !reflect.DeepEqual(got, want) {
+					t.Errorf("depends_on = %v, want %v", got, want)
+				}
+			},
+		},
+		{
+			name:     "build string merges with mapping",
+			base:     "services:\n  web:\n    build: ./app\n",
+			override: "services:\n  web:\n    build:\n      target: prod\n",
+			check: func(t *testing.T, web *ComposeServiceConfig) {
+				if web.Build == nil || web.Build.Target != "prod" || filepath.Base(web.Build.Context) != "app" {
+					t.Errorf("build = %+v", web.Build)
+				}
+			},
+		},
+		{
+			name:     "reset removes a value",
+			base:     "services:\n  web:\n    image: app\n    ports:\n      - \"8080:80\"\n    labels:\n      a: b\n",
+			override: "services:\n  web:\n    ports: !reset []\n    labels: !reset {}\n",
+			check: func(t *testing.T, web *ComposeServiceConfig) {
+				if len(web.Ports) != 0 || len(web.Labels) != 0 {
+					t.Errorf("ports = %+v, labels = %v", web.Ports, web.Labels)
+				}
+			},
+		},
+		{
+			name:     "override replaces instead of merging",
+			base:     "services:\n  web:\n    image: app\n    environment:\n      A: \"1\"\n      B: \"2\"\n",
+			override: "services:\n  web:\n    environment: !override\n      C: \"3\"\n",
+			check: func(t *testing.T, web *ComposeServiceConfig) {
+				if want := map[string]string{"C": "3"}; !reflect.DeepEqual(web.Environment, want) {
+					t.Errorf("environment = %v, want %v", web.Environment, want)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := parseTestFiles(t, tt.base, tt.override)
+			tt.check(t, project.Services["web"])
+		})
+	}
+}
+
+func TestParseFilesOrder(t *testing.T) {
+	project := parseTestFiles(t,
+		"services:\n  b:\n    image: b\n  a:\n    image: a\nvolumes:\n  y: {}\n  x: {}\n",
+		"services:\n  c:\n    image: c\n  a:\n    image: a2\nvolumes:\n  z: {}\n",
+		"services:\n  b:\n    image: b2\n",
+	)
+
+	if want := []string{"b", "a", "c"}; !reflect.DeepEqual(project.ServiceOrder, want) {
+		t.Errorf("ServiceOrder = %v, want %v", project.ServiceOrder, want)
+	}
+	if want := []string{"y", "x", "z"}; !reflect.DeepEqual(project.VolumeOrder, want) {
+		t.Errorf("VolumeOrder = %v, want %v", project.VolumeOrder, want)
+	}
+	if project.Services["a"].Image != "a2" || project.Services["b"].Image != "b2" {
+		t.Errorf("images = %s, %s", project.Services["a"].Image, project.Services["b"].Image)
+	}
+}
+
+func TestSequenceItemKey(t *testing.T) {
+	tests := []struct {
+		field string
+		item  string
+		want  string
+	}{
+		{"ports", `"80"`, "||80|tcp"},
+		{"ports", `"8080:80"`, "|8080|80|tcp"},
+		{"ports", `"127.0.0.1:8080:80/udp"`, "127.0.0.1|8080|80|udp"},
+		{"ports", "{target: 80, published: 8080}", "|8080|80|tcp"},
+		{"ports", "{target: 53, protocol: udp}", "||53|udp"},
+		{"ports", `"[::1]:8080:80"`, "::1|8080|80|tcp"},
+		{"ports", "{target: 80, published: 8080, host_ip: \"::1\"}", "::1|8080|80|tcp"},
+		{"volumes", "/data", "/data"},
+		{"volumes", "./data:/data:ro", "/data"},
+		{"volumes", `C:\data:/data`, "/data"},
+		{"volumes", `C:\data:C:\target:ro`, `C:\target`},
+		{"volumes", "{type: bind, source: ./data, target: /data}", "/data"},
+		{"secrets", "token", "token"},
+		{"secrets", "{source: token}", "token"},
+		{"secrets", "{source: token, target: /run/token}", "/run/token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field+" "+tt.item, func(t *testing.T) {
+			var document yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.item), &document); err != nil {
+				t.Fatalf("invalid test item: %v", err)
+			}
+			if got := sequenceItemKey(tt.field)(document.Content[0]); got != tt.want {
+				t.Errorf("key = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilesEnvFileOverride(t *testing.T) {
+	dir := t.TempDir()
+	base := writeTestFile(t, dir, "base/compose.yaml", "services:\n  web:\n    image: app\n    env_file: ./base.env\n")
+	writeTestFile(t, dir, "base/base.env", "FROM_BASE=1\n")
+	override := writeTestFile(t, dir, "other/override.yaml", "services:\n  web:\n    env_file:\n      - path: ./other.env\n")
+	writeTestFile(t, dir, "other/other.env", "FROM_OTHER=2\n")
+
+	project, err := NewComposeParser(WithEnvironment(map[string]string{})).ParseFiles(base, override)
+	if err != nil {
+		t.Fatalf("ParseFiles: %v", err)
+	}
+
+	web := project.Services["web"]
+	want := map[string]string{"FROM_BASE": "1", "FROM_OTHER": "2"}
+	if !reflect.DeepEqual(web.Environment, want) {
+		t.Errorf("environment = %v, want %v", web.Environment, want)
+	}
+	if len(web.EnvFile) != 2 || web.EnvFile[1].Path != filepath.Join(dir, "other", "other.env") {
+		t.Errorf("env_file = %+v", web.EnvFile)
+	}
+}
@@ -0,0 +1,143 @@
+package compose_parser
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Имя проекта для данных без файла, если оно не задано другим способом
+const defaultProjectName = "docker-compose"
+
+// Переменная окружения с именем проекта
+const projectNameVariable = "COMPOSE_PROJECT_NAME"
+
+// projectNamePattern описывает допустимое имя проекта
+var projectNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// projectNameInvalidChars описывает символы, удаляемые при нормализации имени проекта
+var projectNameInvalidChars = regexp.MustCompile(`[^-_a-z0-9]+`)
+
+// NormalizeProjectName приводит имя к виду, допустимому для имени проекта Compose:
+// переводит в нижний регистр, удаляет символы кроме [a-z0-9_-] и начальные "_" и "-".
+// Например, "My App.v2" становится "myappv2". Может вернуть пустую строку
+func NormalizeProjectName(name string) string {
+	name = projectNameInvalidChars.ReplaceAllString(strings.ToLower(name), "")
+	return strings.TrimLeft(name, "_-")
+}
+
+// validateProjectName проверяет явно заданное имя проекта или имя из переменной окружения
+func validateProjectName(name string, source string) error {
+	if projectNamePattern.MatchString(name) {
+		return nil
+	}
+	validationErr := invalidValueError(nil, "name", "invalid project name %q from %s: must consist only of lowercase "+
+		"alphanumeric characters, hyphens, and underscores as well as start with a letter or number", name, source)
+	validationErr.Value = name
+	return validationErr
+}
+
+// projectContext определяет имя проекта и создает состояние парсинга, в котором
+// для подстановки в файлах проекта доступны переменные файла .env директории
+// проекта и COMPOSE_PROJECT_NAME.
+// names - значения ключа name из файлов проекта по порядку, projectDir - директория
+// проекта или "" для данных без файла
+func (p *ComposeParser) projectContext(projectName string, names []string, projectDir string) (*parseContext, string, error) {
+	lookup := p.lookupFunc()
+	if projectDir != "" {
+		dotenv, err := loadProjectEnv(projectDir, lookup)
+		if err != nil {
+			return nil, "", err
+		}
+		// Переменные окружения имеют приоритет над файлом .env
+		lookup = chainLookup(lookup, MapLookup(dotenv))
+	}
+
+	name, err := p.resolveProjectName(projectName, names, projectDir, lookup)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Значение из окружения имеет приоритет, поэтому источник с именем проекта опрашивается последним
+	lookup = chainLookup(lookup, MapLookup(map[string]string{projectNameVariable: name}))
+	ctx := newParseContext(lookup)
+	ctx.workingDir = projectDir
+	return ctx, name, nil
+}
+
+// resolveProjectName определяет имя проекта в порядке Compose: явно указанное имя,
+// переменная COMPOSE_PROJECT_NAME, ключ name (последний заданный среди файлов),
+// директория проекта. Явное имя и переменная проверяются, как -p и COMPOSE_PROJECT_NAME в Compose,
+// имена из ключа name и директории нормализуются
+func (p *ComposeParser) resolveProjectName(projectName string, names []string, projectDir string, lookup LookupFunc) (string, error) {
+	if projectName != "" {
+		return projectName, validateProjectName(projectName, "the projectName argument")
+	}
+	if value, ok := lookup(projectNameVariable); ok && value != "" {
+		return value, validateProjectName(value, projectNameVariable)
+	}
+
+	name := ""
+	for _, value := range names {
+		if !p.disableInterpolate {
+			interpolated, err := Interpolate(value, lookup)
+			if err != nil {
+				return "", newValidationError(ErrCodeInterpolation, nil, "name", "%v", err)
+			}
+			value = interpolated
+		}
+		if normalized := NormalizeProjectName(value); normalized != "" {
+			name = normalized
+		}
+	}
+	if name != "" {
+		return name, nil
+	}
+
+	if projectDir == "" {
+		return defaultProjectName, nil
+	}
+	name = NormalizeProjectName(filepath.Base(projectDir))
+	if name == "" {
+		return "", invalidValueError(nil, "name",
+			"project name cannot be derived from directory %s, specify it explicitly", projectDir)
+	}
+	return name, nil
+}
+
+// rawProjectName возвращает значение ключа name документа до подстановки переменных.
+// Ошибки разбора не возвращаются, они сообщаются при загрузке документа
+func rawProjectName(data []byte) string {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil || len(document.Content) == 0 {
+		return ""
+	}
+	nameNode := mappingValue(document.Content[0], "name")
+	if nameNode == nil || nameNode.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return nameNode.Value
+}
+
+// fileProjectContext определяет имя проекта для файлов и создает состояние парсинга.
+// Директорией проекта считается директория первого файла
+func (p *ComposeParser) fileProjectContext(projectName string, paths []string) (*parseContext, string, error) {
+	absFile, err := filepath.Abs(paths[0])
+	if err != nil {
+		return nil, "", err
+	}
+
+	var names []string
+	if projectName == "" {
+		for _, path := range paths {
+			// Ошибки чтения сообщаются при загрузке файла
+			if data, err := os.ReadFile(path); err == nil {
+				names = append(names, rawProjectName(data))
+			}
+		}
+	}
+	return p.projectContext(projectName, names, filepath.Dir(absFile))
+}
@@ -0,0 +1,282 @@
+package compose_parser
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parsePorts парсит маппинг портов
+func (p *ComposeParser) parsePorts(ctx *parseContext, node *yaml.Node, path string) ([]PortMapping, error) {
+	var ports []PortMapping
+
+	err := forEachItem(node, path, func(item *yaml.Node, path string) error {
+		port, err := p.parsePort(item, path)
+		if err != nil {
+			return err
+		}
+		// Все маппинги развернутого диапазона указывают на один элемент списка
+		location := ctx.locationAt(path)
+		for i := range port {
+			port[i].Location = location
+		}
+		ports = append(ports, port...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ports, nil
+}
+
+// parsePort парсит один порт в короткой или полной форме.
+// Диапазоны портов короткой формы разворачиваются в отдельные маппинги
+func (p *ComposeParser) parsePort(node *yaml.Node, path string) ([]PortMapping, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		// Строка или одиночный номер порта без кавычек, например "- 80"
+		ports, err := p.parsePortString(node.Value)
+		if err != nil {
+			return nil, invalidValueError(node, path, "%v", err)
+		}
+		return ports, nil
+
+	case yaml.MappingNode:
+		port, err := p.parsePortMap(node, path)
+		if err != nil {
+			return nil, err
+		}
+		return []PortMapping{*port}, nil
+
+	default:
+		return nil, newTypeError(node, path, "a string, a number or a mapping")
+	}
+}
+
+// parsePortString парсит короткую форму порта:
+// [[host_ip:][published[-end]]:]target[-end][/protocol], IPv6 адрес задается в квадратных скобках
+func (p *ComposeParser) parsePortString(spec string) ([]PortMapping, error) {
+	value := strings.TrimSpace(spec)
+	if value == "" {
+		return nil, fmt.Errorf("invalid port format: empty port")
+	}
+
+	protocol := ""
+	if idx := strings.LastIndex(value, "/"); idx >= 0 {
+		protocol = value[idx+1:]
+		value = value[:idx]
+		if err := validatePortProtocol(protocol); err != nil {
+			return nil, fmt.Errorf("invalid port format %q: %v", spec, err)
+		}
+	}
+
+	var hostIP, published, target string
+	if strings.HasPrefix(value, "[") {
+		// IPv6 адрес хоста: [::1]:published:target
+		end := strings.Index(value, "]")
+		if end < 0 || end+1 >= len(value) || value[end+1] != ':' {
+			return nil, fmt.Errorf("invalid port format %q: malformed IPv6 address", spec)
+		}
+		hostIP = value[1:end]
+		parts := strings.Split(value[end+2:], ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid port format %q", spec)
+		}
+		published, target = parts[0], parts[1]
+	} else {
+		parts := strings.Split(value, ":")
+		switch len(parts) {
+		case 1:
+			target = parts[0]
+		case 2:
+			published, target = parts[0], parts[1]
+		case 3:
+			hostIP, published, target = parts[0], parts[1], parts[2]
+			if hostIP == "" {
+				return nil, fmt.Errorf("invalid port format %q: empty host IP", spec)
+			}
+		default:
+			return nil, fmt.Errorf("invalid port format %q: IPv6 host addresses must be enclosed in brackets", spec)
+		}
+	}
+
+	if hostIP != "" && net.ParseIP(hostIP) == nil {
+		return nil, fmt.Errorf("invalid port format %q: invalid host IP %s", spec, hostIP)
+	}
+
+	targetStart, targetEnd, err := parsePortRange(target, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port format %q: %v", spec, err)
+	}
+
+	mapping := PortMapping{HostIP: hostIP, Protocol: protocol}
+
+	// Без опубликованного порта каждый целевой порт диапазона публикуется на случайный порт
+	if published == "" {
+		var ports []PortMapping
+		for port := targetStart; port <= targetEnd; port++ {
+			mapping.Target = uint16(port)
+			ports = append(ports, mapping)
+		}
+		return ports, nil
+	}
+
+	publishedStart, publishedEnd, err := parsePortRange(published, true)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port format %q: %v", spec, err)
+	}
+
+	targetCount := targetEnd - targetStart + 1
+	publishedCount := publishedEnd - publishedStart + 1
+
+	switch {
+	case targetCount == 1:
+		// Один целевой порт может публиковаться на любой свободный порт диапазона
+		mapping.Target = uint16(targetStart)
+		mapping.Published = uint16(publishedStart)
+		if publishedCount > 1 {
+			mapping.PublishedEnd = uint16(publishedEnd)
+		}
+		return []PortMapping{mapping}, nil
+
+	case targetCount == publishedCount:
+		ports := make([]PortMapping, 0, targetCount)
+		for i := 0; i < targetCount; i++ {
+			mapping.Target = uint16(targetStart + i)
+			mapping.Published = uint16(publishedStart + i)
+			ports = append(ports, mapping)
+		}
+		return ports, nil
+
+	default:
+		return nil, fmt.Errorf("invalid port format %q: published and target port ranges must have the same length", spec)
+	}
+}
+
+// parsePortMap парсит полную форму порта
+func (p *ComposeParser) parsePortMap(node *yaml.Node, path string) (*PortMapping, error) {
+	port := &PortMapping{}
+
+	d := p.newNodeDecoder(node, path)
+	if !d.has("target") {
+		return nil, requiredFieldError(node, path, "target")
+	}
+
+	d.field("target", func(node *yaml.Node, path string) error {
+		target, err := decodeString(node, path)
+		if err != nil {
+			return err
+		}
+		start, end, err := parsePortRange(target, false)
+		if err != nil {
+			return invalidValueError(node, path, "%v", err)
+		}
+		// Диапазоны целевых портов допускаются только в короткой форме
+		if end != start {
+			return invalidValueError(node, path, "target port range %s is not supported in the long syntax, use one entry per port", target)
+		}
+		port.Target = uint16(start)
+		return nil
+	})
+
+	d.field("published", func(node *yaml.Node, path string) error {
+		published, err := decodeString(node, path)
+		if err != nil || published == "" {
+			return err
+		}
+		start, end, err := parsePortRange(published, true)
+		if err != nil {
+			return invalidValueError(node, path, "%v", err)
+		}
+		port.Published = uint16(start)
+		if end != start {
+			port.PublishedEnd = uint16(end)
+		}
+		return nil
+	})
+
+	d.field("host_ip", func(node *yaml.Node, path string) (err error) {
+		port.HostIP, err = decodeString(node, path)
+		if err == nil && net.ParseIP(port.HostIP) == nil {
+			err = invalidValueError(node, path, "invalid host IP %s", port.HostIP)
+		}
+		return err
+	})
+
+	d.field("protocol", func(node *yaml.Node, path string) (err error) {
+		port.Protocol, err = decodeString(node, path)
+		if err == nil {
+			if err = validatePortProtocol(port.Protocol); err != nil {
+				err = invalidValueError(node, path, "%v", err)
+			}
+		}
+		return err
+	})
+
+	port.AppProtocol = d.stringField("app_protocol")
+
+	d.field("mode", func(node *yaml.Node, path string) (err error) {
+		port.Mode, err = decodeString(node, path)
+		if err == nil && port.Mode != "host" && port.Mode != "ingress" {
+			err = invalidValueError(node, path, "invalid port mode %s, expected host or ingress", port.Mode)
+		}
+		return err
+	})
+
+	port.Name = d.stringField("name")
+
+	if err := d.Err(); err != nil {
+		return nil, err
+	}
+
+	return port, nil
+}
+
+// parsePortRange парсит номер порта или диапазон "start-end".
+// Нулевой порт допускается только для опубликованных портов
+func parsePortRange(value string, allowZero bool) (int, int, error) {
+	startRaw, endRaw := value, value
+	if idx := strings.Index(value, "-"); idx >= 0 {
+		startRaw, endRaw = value[:idx], value[idx+1:]
+	}
+
+	start, err := parsePortNumber(startRaw, allowZero)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parsePortNumber(endRaw, allowZero)
+	if err != nil {
+		return 0, 0, err
+	}
+	if end < start {
+		return 0, 0, fmt.Errorf("invalid port range: %s", value)
+	}
+
+	return start, end, nil
+}
+
+// parsePortNumber парсит номер порта из строки
+func parsePortNumber(s string, allowZero bool) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid port number: %q", s)
+	}
+	if port < 0 || port > 65535 || (port == 0 && !allowZero) {
+		return 0, fmt.Errorf("port number out of range: %d", port)
+	}
+	return port, nil
+}
+
+// validatePortProtocol проверяет протокол порта
+func validatePortProtocol(protocol string) error {
+	switch strings.ToLower(protocol) {
+	case "tcp", "udp", "sctp":
+		return nil
+	default:
+		return fmt.Errorf("invalid port protocol: %q, expected tcp, udp or sctp", protocol)
+	}
+}
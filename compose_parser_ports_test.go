@@ -0,0 +1,159 @@
+package compose_parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePortString(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []PortMapping
+	}{
+		{"80", []PortMapping{{Target: 80}}},
+		{"80/udp", []PortMapping{{Target: 80, Protocol: "udp"}}},
+		{"8080:80", []PortMapping{{Target: 80, Published: 8080}}},
+		{"0:80", []PortMapping{{Target: 80}}},
+		{"127.0.0.1:8080:80", []PortMapping{{Target: 80, Published: 8080, HostIP: "127.0.0.1"}}},
+		{"127.0.0.1::80", []PortMapping{{Target: 80, HostIP: "127.0.0.1"}}},
+		{"[::1]:6001:6001", []PortMapping{{Target: 6001, Published: 6001, HostIP: "::1"}}},
+		{"[::1]::6001/tcp", []PortMapping{{Target: 6001, HostIP: "::1", Protocol: "tcp"}}},
+		{"3000-3001", []PortMapping{{Target: 3000}, {Target: 3001}}},
+		{"9090-9091:8080-8081", []PortMapping{
+			{Target: 8080, Published: 9090},
+			{Target: 8081, Published: 9091},
+		}},
+		{"8000-8010:80", []PortMapping{{Target: 80, Published: 8000, PublishedEnd: 8010}}},
+		{"127.0.0.1:5000-5001:5000-5001/sctp", []PortMapping{
+			{Target: 5000, Published: 5000, HostIP: "127.0.0.1", Protocol: "sctp"},
+			{Target: 5001, Published: 5001, HostIP: "127.0.0.1", Protocol: "sctp"},
+		}},
+		{" 443 ", []PortMapping{{Target: 443}}},
+	}
+
+	parser := NewComposeParser()
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := parser.parsePortString(tt.spec)
+			if err != nil {
+				t.Fatalf("parsePortString(%q): %v", tt.spec, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePortString(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePortStringErrors(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr string
+	}{
+		{"", "empty port"},
+		{"abc", "invalid port number"},
+		{"0", "out of range"},
+		{"70000", "out of range"},
+		{"8080:0", "out of range"},
+		{"80/icmp", "invalid port protocol"},
+		{"::1:8080:80", "must be enclosed in brackets"},
+		{"[::1:8080:80", "malformed IPv6 address"},
+		{"[::1]:80", "invalid port format"},
+		{"300.0.0.1:8080:80", "invalid host IP"},
+		{":8080:80", "empty host IP"},
+		{"81-80", "invalid port range"},
+		{"9090-9092:8080-8081", "same length"},
+	}
+
+	parser := NewComposeParser()
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := parser.parsePortString(tt.spec)
+			if err == nil {
+				t.Fatalf("parsePortString(%q): expected an error", tt.spec)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parsePortString(%q) error = %q, want it to contain %q", tt.spec, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParsePortsLongSyntax(t *testing.T) {
+	tests := []struct {
+		name    string
+		port    string
+		want    []PortMapping
+		wantErr string
+	}{
+		{
+			name: "all fields",
+			port: "target: 80\n        published: \"8080\"\n        host_ip: 127.0.0.1\n        protocol: tcp\n        app_protocol: http\n        mode: host\n        name: web",
+			want: []PortMapping{{Name: "web", Target: 80, Published: 8080, HostIP: "127.0.0.1", Protocol: "tcp", AppProtocol: "http", Mode: "host"}},
+		},
+		{
+			name: "published as number",
+			port: "target: 80\n        published: 8080",
+			want: []PortMapping{{Target: 80, Published: 8080}},
+		},
+		{
+			name: "published range",
+			port: "target: 80\n        published: 8000-8010",
+			want: []PortMapping{{Target: 80, Published: 8000, PublishedEnd: 8010}},
+		},
+		{
+			name: "IPv6 host",
+			port: "target: 80\n        host_ip: \"::1\"",
+			want: []PortMapping{{Target: 80, HostIP: "::1"}},
+		},
+		{name: "missing target", port: "published: 8080", wantErr: "target is required"},
+		{name: "target range", port: "target: 80-81", wantErr: "not supported in the long syntax"},
+		{name: "invalid mode", port: "target: 80\n        mode: bridge", wantErr: "invalid port mode"},
+		{name: "invalid protocol", port: "target: 80\n        protocol: icmp", wantErr: "invalid port protocol"},
+		{name: "invalid host IP", port: "target: 80\n        host_ip: localhost", wantErr: "invalid host IP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := "services:\n  web:\n    image: app\n    ports:\n      - " + tt.port + "\n"
+			project, err := NewComposeParser().ParseYAML([]byte(document))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseYAML: %v", err)
+			}
+
+			got := project.Services["web"].Ports
+			for i := range got {
+				got[i].Location = nil
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ports = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePortsLocation(t *testing.T) {
+	document := "services:\n  web:\n    image: app\n    ports:\n      - 80\n      - \"9090-9091:8080-8081\"\n"
+	project, err := NewComposeParser().ParseYAML([]byte(document))
+	if err != nil {
+		t.Fatalf("ParseYAML: %v", err)
+	}
+
+	ports := project.Services["web"].Ports
+	if len(ports) != 3 {
+		t.Fatalf("ports = %+v, want 3 mappings", ports)
+	}
+	// Маппинги развернутого диапазона указывают на один элемент списка
+	for i, wantLine := range []int{5, 6, 6} {
+		if ports[i].Location == nil || ports[i].Location.Line != wantLine {
+			t.Errorf("ports[%d].Location = %v, want line %d", i, ports[i].Location, wantLine)
+		}
+	}
+}
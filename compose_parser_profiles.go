@@ -0,0 +1,198 @@
+package compose_parser
+
+import (
+	"strings"
+)
+
+// ActiveProfiles возвращает активные профили из переменной COMPOSE_PROFILES
+// (список через запятую) с учетом источника переменных парсера
+func (p *ComposeParser) ActiveProfiles() []string {
+	value, ok := p.lookupFunc()("COMPOSE_PROFILES")
+	if !ok {
+		return nil
+	}
+
+	var profiles []string
+	for _, profile := range strings.Split(value, ",") {
+		if profile = strings.TrimSpace(profile); profile != "" {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
+
+// ApplyProfiles возвращает представление проекта, содержащее только сервисы, включенные
+// для указанных профилей. Если profiles равен nil, используются профили из COMPOSE_PROFILES
+// (см. ActiveProfiles). Профиль "*" включает все сервисы. Явно указанные сервисы
+// включаются вместе с зависимостями независимо от профилей. Зависимости от выключенных
+// сервисов, а также сети, тома, секреты и конфигурации, которые используются только
+// выключенными сервисами, удаляются
+func (p *ComposeParser) ApplyProfiles(project *ComposeProjectConfig, profiles []string, services ...string) (*ComposeProjectConfig, error) {
+	if profiles == nil {
+		profiles = p.ActiveProfiles()
+	}
+
+	active := make(map[string]bool)
+	for _, profile := range profiles {
+		active[profile] = true
+	}
+
+	enabled := make(map[string]bool)
+	for name, service := range project.Services {
+		if isServiceEnabled(service, active) {
+			enabled[name] = true
+		}
+	}
+
+	// Явно указанные сервисы включаются вместе со своими зависимостями
+	var enable func(name string)
+	enable = func(name string) {
+		if enabled[name] {
+			return
+		}
+		enabled[name] = true
+		for _, dependency := range project.Services[name].DependsOn {
+			if _, exists := project.Services[dependency.Service]; exists {
+				enable(dependency.Service)
+			}
+		}
+	}
+	for _, name := range services {
+		if _, exists := project.Services[name]; !exists {
+			return nil, newReferenceError(ErrCodeUndefinedReference, nil, "", "service", name, "no such service: %s", name)
+		}
+		enable(name)
+	}
+
+	view := *project
+	view.Services = make(map[string]*ComposeServiceConfig)
+	view.ServiceOrder = make([]string, 0, len(project.ServiceOrder))
+	for _, name := range project.ServiceOrder {
+		if enabled[name] {
+			view.ServiceOrder = append(view.ServiceOrder, name)
+		}
+	}
+
+	for name, service := range project.Services {
+		if !enabled[name] {
+			continue
+		}
+
+		serviceCopy := *service
+		serviceCopy.DependsOn = nil
+		for _, dependency := range service.DependsOn {
+			if enabled[dependency.Service] {
+				serviceCopy.DependsOn = append(serviceCopy.DependsOn, dependency)
+			}
+		}
+		view.Services[name] = &serviceCopy
+	}
+
+	// Удаляем сети, тома, секреты и конфигурации, которые перестали использоваться
+	used := collectResourceUsage(project.Services, nil)
+	usedByEnabled := collectResourceUsage(project.Services, enabled)
+
+	view.Networks = make(map[string]*NetworkConfig)
+	for name, network := range project.Networks {
+		if used.networks[name] && !usedByEnabled.networks[name] {
+			continue
+		}
+		view.Networks[name] = network
+	}
+
+	view.Volumes = make(map[string]*VolumeConfig)
+	view.VolumeOrder = make([]string, 0, len(project.VolumeOrder))
+	for _, name := range project.VolumeOrder {
+		if used.volumes[name] && !usedByEnabled.volumes[name] {
+			continue
+		}
+		if volume, exists := project.Volumes[name]; exists {
+			view.Volumes[name] = volume
+			view.VolumeOrder = append(view.VolumeOrder, name)
+		}
+	}
+
+	view.Secrets = make(map[string]*SecretConfig)
+	for name, secret := range project.Secrets {
+		if used.secrets[name] && !usedByEnabled.secrets[name] {
+			continue
+		}
+		view.Secrets[name] = secret
+	}
+
+	view.Configs = make(map[string]*ConfigConfig)
+	for name, config := range project.Configs {
+		if used.configs[name] && !usedByEnabled.configs[name] {
+			continue
+		}
+		view.Configs[name] = config
+	}
+
+	return &view, nil
+}
+
+// isServiceEnabled проверяет, включен ли сервис для набора активных профилей
+func isServiceEnabled(service *ComposeServiceConfig, active map[string]bool) bool {
+	if len(service.Profiles) == 0 || active["*"] {
+		return true
+	}
+	for _, profile := range service.Profiles {
+		if active[profile] {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceUsage содержит имена ресурсов верхнего уровня, используемых сервисами
+type resourceUsage struct {
+	networks map[string]bool
+	volumes  map[string]bool
+	secrets  map[string]bool
+	configs  map[string]bool
+}
+
+// collectResourceUsage возвращает сети, тома, секреты и конфигурации, используемые сервисами.
+// Если filter не nil, учитываются только сервисы из filter
+func collectResourceUsage(services map[string]*ComposeServiceConfig, filter map[string]bool) *resourceUsage {
+	usage := &resourceUsage{
+		networks: make(map[string]bool),
+		volumes:  make(map[string]bool),
+		secrets:  make(map[string]bool),
+		configs:  make(map[string]bool),
+	}
+
+	for name, service := range services {
+		if filter != nil && !filter[name] {
+			continue
+		}
+
+		// Сервис без явных сетей подключается к сети default
+		if len(service.Networks) == 0 && service.NetworkMode == "" {
+			usage.networks["default"] = true
+		}
+		for _, network := range service.Networks {
+			usage.networks[network.Name] = true
+		}
+
+		for _, volume := range service.Volumes {
+			if volume.IsNamed() {
+				usage.volumes[volume.Source] = true
+			}
+		}
+
+		for _, secret := range service.Secrets {
+			usage.secrets[secret.Source] = true
+		}
+		if service.Build != nil {
+			for _, secret := range service.Build.Secrets {
+				usage.secrets[secret.Source] = true
+			}
+		}
+		for _, config := range service.Configs {
+			usage.configs[config.Source] = true
+		}
+	}
+
+	return usage
+}
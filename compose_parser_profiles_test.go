@@ -0,0 +1,168 @@
+package compose_parser
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+const profilesDocument = `services:
+  web:
+    image: nginx
+    secrets:
+      - shared
+    networks:
+      - front
+  debug:
+    image: busybox
+    profiles: [debug]
+    secrets:
+      - debug_token
+      - shared
+    configs:
+      - debug_config
+    volumes:
+      - debug_data:/data
+    networks:
+      - debug_net
+  migrate:
+    image: migrate
+    profiles: [tools]
+    depends_on:
+      - db
+  db:
+    image: postgres
+    profiles: [tools]
+networks:
+  front: {}
+  debug_net: {}
+volumes:
+  debug_data: {}
+secrets:
+  shared:
+    file: ./shared.txt
+  debug_token:
+    file: ./debug.txt
+  unused:
+    file: ./unused.txt
+configs:
+  debug_config:
+    file: ./debug.conf
+`
+
+func TestApplyProfiles(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		profiles     []string
+		services     []string
+		wantServices []string
+		wantNetworks []string
+		wantVolumes  []string
+		wantSecrets  []string
+		wantConfigs  []string
+	}{
+		{
+			name:         "no profiles",
+			profiles:     []string{},
+			wantServices: []string{"web"},
+			wantNetworks: []string{"front"},
+			wantSecrets:  []string{"shared", "unused"},
+		},
+		{
+			name:         "profile enabled",
+			profiles:     []string{"debug"},
+			wantServices: []string{"debug", "web"},
+			wantNetworks: []string{"debug_net", "front"},
+			wantVolumes:  []string{"debug_data"},
+			wantSecrets:  []string{"debug_token", "shared", "unused"},
+			wantConfigs:  []string{"debug_config"},
+		},
+		{
+			name:         "COMPOSE_PROFILES when profiles is nil",
+			env:          map[string]string{"COMPOSE_PROFILES": "debug, tools"},
+			wantServices: []string{"db", "debug", "migrate", "web"},
+			wantNetworks: []string{"debug_net", "front"},
+			wantVolumes:  []string{"debug_data"},
+			wantSecrets:  []string{"debug_token", "shared", "unused"},
+			wantConfigs:  []string{"debug_config"},
+		},
+		{
+			name:         "explicit profiles override COMPOSE_PROFILES",
+			env:          map[string]string{"COMPOSE_PROFILES": "debug"},
+			profiles:     []string{},
+			wantServices: []string{"web"},
+			wantNetworks: []string{"front"},
+			wantSecrets:  []string{"shared", "unused"},
+		},
+		{
+			name:         "all profiles",
+			profiles:     []string{"*"},
+			wantServices: []string{"db", "debug", "migrate", "web"},
+			wantNetworks: []string{"debug_net", "front"},
+			wantVolumes:  []string{"debug_data"},
+			wantSecrets:  []string{"debug_token", "shared", "unused"},
+			wantConfigs:  []string{"debug_config"},
+		},
+		{
+			name:         "targeted service with dependencies",
+			profiles:     []string{},
+			services:     []string{"migrate"},
+			wantServices: []string{"db", "migrate", "web"},
+			wantNetworks: []string{"front"},
+			wantSecrets:  []string{"shared", "unused"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := tt.env
+			if env == nil {
+				env = map[string]string{}
+			}
+			parser := NewComposeParser(WithEnvironment(env))
+			project, err := parser.ParseYAML([]byte(profilesDocument))
+			if err != nil {
+				t.Fatalf("ParseYAML: %v", err)
+			}
+
+			view, err := parser.ApplyProfiles(project, tt.profiles, tt.services...)
+			if err != nil {
+				t.Fatalf("ApplyProfiles: %v", err)
+			}
+
+			checkNames(t, "services", view.Services, tt.wantServices)
+			checkNames(t, "networks", view.Networks, tt.wantNetworks)
+			checkNames(t, "volumes", view.Volumes, tt.wantVolumes)
+			checkNames(t, "secrets", view.Secrets, tt.wantSecrets)
+			checkNames(t, "configs", view.Configs, tt.wantConfigs)
+		})
+	}
+}
+
+func TestApplyProfilesUnknownService(t *testing.T) {
+	parser := NewComposeParser(WithEnvironment(map[string]string{}))
+	project, err := parser.ParseYAML([]byte(profilesDocument))
+	if err != nil {
+		t.Fatalf("ParseYAML: %v", err)
+	}
+	if _, err := parser.ApplyProfiles(project, nil, "missing"); err == nil {
+		t.Fatal("expected an error for an unknown service")
+	}
+}
+
+// checkNames сравнивает отсортированные ключи map с ожидаемыми именами
+func checkNames[V any](t *testing.T, kind string, elements map[string]V, want []string) {
+	t.Helper()
+	names := make([]string, 0, len(elements))
+	for name := range elements {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if want == nil {
+		want = []string{}
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("%s = %v, want %v", kind, names, want)
+	}
+}
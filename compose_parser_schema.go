@@ -0,0 +1,167 @@
+package compose_parser
+
+import (
+	"embed"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schemaFiles содержит встроенные JSON-схемы Compose, см. schema/README.md
+//
+//go:embed schema/*.json
+var schemaFiles embed.FS
+
+// Имя схемы спецификации Compose
+const composeSpecSchema = "compose-spec.json"
+
+// schemaCache содержит разобранные схемы по имени файла
+var schemaCache sync.Map
+
+// composeSpecAdditions дополняет встроенную спецификацию Compose полями более новых версий
+// спецификации, чтобы режим WithStrict и ValidateFile не отвергали поля, которые разбирает
+// парсер. Сами файлы схем не изменяются, см. schema/README.md
+var composeSpecAdditions = []schemaAddition{
+	{"#/definitions/service/properties/networks/oneOf/1/patternProperties/^[a-zA-Z0-9._-]+$/oneOf/0", "gw_priority", `{"type": "number"}`},
+	{"#/definitions/service/properties/networks/oneOf/1/patternProperties/^[a-zA-Z0-9._-]+$/oneOf/0", "interface_name", `{"type": "string"}`},
+	{"#/definitions/network", "enable_ipv4", `{"type": "boolean"}`},
+	{"#/definitions/service/properties/volumes/items/oneOf/1", "image", `{"type": "object", "properties": {"subpath": {"type": "string"}}, "additionalProperties": false, "patternProperties": {"^x-": {}}}`},
+	{"#/definitions/env_file/oneOf/1/items/oneOf/1", "format", `{"type": "string"}`},
+}
+
+// loadSchema возвращает встроенную схему по имени файла
+func loadSchema(name string) (*jsonSchema, error) {
+	if schema, ok := schemaCache.Load(name); ok {
+		return schema.(*jsonSchema), nil
+	}
+
+	data, err := schemaFiles.ReadFile("schema/" + name)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := parseJSONSchema(name, data)
+	if err != nil {
+		return nil, err
+	}
+	if name == composeSpecSchema {
+		if err := schema.extend(composeSpecAdditions); err != nil {
+			return nil, err
+		}
+	}
+
+	actual, _ := schemaCache.LoadOrStore(name, schema)
+	return actual.(*jsonSchema), nil
+}
+
+// ValidateFile проверяет Docker Compose файл по JSON-схеме спецификации Compose.
+// Проверяется документ после подстановки переменных, подключаемые через include
+// и extends файлы не проверяются. Нарушения схемы возвращаются как диагностики
+// с путем и положением элемента, ошибка возвращается, если файл не удалось прочитать
+func (p *ComposeParser) ValidateFile(filePath string) ([]Diagnostic, error) {
+	ctx, _, err := p.fileProjectContext("", []string{filePath})
+	if err != nil {
+		return nil, err
+	}
+	rootNode, err := p.readFile(ctx, filePath, ctx.lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.validateSchema(ctx, rootNode, absPath); err != nil {
+		return nil, err
+	}
+	return sortDiagnostics(ctx.diagnostics), nil
+}
+
+// ValidateYAML проверяет YAML данные по JSON-схеме спецификации Compose
+func (p *ComposeParser) ValidateYAML(data []byte) ([]Diagnostic, error) {
+	ctx := newParseContext(p.lookupFunc())
+	rootNode, err := p.loadDocument(ctx, data, ctx.lookup, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.validateSchema(ctx, rootNode, ""); err != nil {
+		return nil, err
+	}
+	return sortDiagnostics(ctx.diagnostics), nil
+}
+
+// validateSchema проверяет документ по выбранной схеме и добавляет нарушения в диагностики
+func (p *ComposeParser) validateSchema(ctx *parseContext, rootNode *yaml.Node, file string) error {
+	schema, err := p.selectSchema(ctx, rootNode, file)
+	if err != nil {
+		return err
+	}
+
+	for _, violation := range schema.validate(rootNode) {
+		ctx.diagnose(SeverityError, violation, file)
+	}
+	return nil
+}
+
+// checkUnknownFields в режиме WithStrict проверяет, что все поля документа описаны
+// в спецификации Compose. Без режима WithErrorTolerance возвращается первое неизвестное поле
+func (p *ComposeParser) checkUnknownFields(ctx *parseContext, rootNode *yaml.Node) error {
+	schema, err := p.selectSchema(ctx, rootNode, "")
+	if err != nil {
+		return err
+	}
+
+	var unknown []Diagnostic
+	for _, violation := range schema.validate(rootNode) {
+		if _, ok := violation.(*UnknownFieldError); ok {
+			unknown = append(unknown, newDiagnostic(SeverityError, ctx.completeError(violation, "")))
+		}
+	}
+
+	for _, diagnostic := range sortDiagnostics(unknown) {
+		if err := p.tolerate(ctx, diagnostic.Err, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectSchema возвращает схему для проверки документа
+func (p *ComposeParser) selectSchema(ctx *parseContext, rootNode *yaml.Node, file string) (*jsonSchema, error) {
+	name := composeSpecSchema
+	if p.legacySchemas {
+		name = p.legacySchemaName(ctx, rootNode, file)
+	}
+	return loadSchema(name)
+}
+
+// legacySchemaName выбирает схему формата 3.x по ключу version, версия "3" соответствует "3.0".
+// Формат 2.x не поддерживается: для него и версий без встроенной схемы используется
+// спецификация Compose с предупреждением
+func (p *ComposeParser) legacySchemaName(ctx *parseContext, rootNode *yaml.Node, file string) string {
+	versionNode := mappingValue(rootNode, "version")
+	if versionNode == nil || versionNode.Kind != yaml.ScalarNode || versionNode.Value == "" {
+		return composeSpecSchema
+	}
+
+	version := strings.TrimSpace(versionNode.Value)
+	if version == "3" {
+		version = "3.0"
+	}
+
+	name := "config_schema_v" + version + ".json"
+	if _, err := schemaFiles.Open("schema/" + name); err == nil {
+		return name
+	}
+
+	message := "no schema for Compose file version %s, validating against the Compose Specification"
+	if version == "2" || strings.HasPrefix(version, "2.") {
+		message = "Compose file format %s is not supported by legacy schemas (3.x only), validating against the Compose Specification"
+	}
+	warning := newValidationError(WarnCodeLegacySchema, versionNode, "version", message, version)
+	ctx.diagnose(SeverityWarning, warning, file)
+	return composeSpecSchema
+}
@@ -0,0 +1,76 @@
+package compose_parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLegacySchemaSelection(t *testing.T) {
+	// healthcheck.start_period появился в формате 3.4 и есть в спецификации Compose
+	const service = "services:\n  web:\n    image: app\n    healthcheck:\n      test: [CMD, \"true\"]\n      start_period: 10s\n"
+
+	tests := []struct {
+		name        string
+		version     string
+		wantError   string
+		wantWarning string
+	}{
+		{name: "no version uses the spec"},
+		{name: "version 3 maps to 3.0", version: `"3"`, wantError: "services.web.healthcheck.start_period"},
+		{name: "version 3.0", version: `"3.0"`, wantError: "services.web.healthcheck.start_period"},
+		{name: "version 3.4", version: `"3.4"`},
+		{name: "version 3.13", version: `"3.13"`},
+		{name: "format 2.x", version: `"2.4"`, wantWarning: "not supported by legacy schemas (3.x only)"},
+		{name: "format 2", version: `"2"`, wantWarning: "Compose file format 2 is not supported"},
+		{name: "unknown version", version: `"9.9"`, wantWarning: "no schema for Compose file version 9.9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := service
+			if tt.version != "" {
+				document = "version: " + tt.version + "\n" + service
+			}
+			diagnostics, err := NewComposeParser(WithLegacySchemas()).ValidateYAML([]byte(document))
+			if err != nil {
+				t.Fatalf("ValidateYAML: %v", err)
+			}
+
+			var errorPaths, warnings []string
+			for _, diagnostic := range diagnostics {
+				switch {
+				case diagnostic.Severity == SeverityError:
+					errorPaths = append(errorPaths, diagnostic.Path)
+				case diagnostic.Code == WarnCodeLegacySchema:
+					warnings = append(warnings, diagnostic.Message)
+					if diagnostic.Path != "version" || diagnostic.Location == nil {
+						t.Errorf("warning path = %q, location = %v", diagnostic.Path, diagnostic.Location)
+					}
+				}
+			}
+
+			if got := strings.Join(errorPaths, ", "); got != tt.wantError {
+				t.Errorf("error paths = %q, want %q", got, tt.wantError)
+			}
+			if tt.wantWarning == "" && len(warnings) != 0 {
+				t.Errorf("unexpected warnings %q", warnings)
+			}
+			if tt.wantWarning != "" && (len(warnings) != 1 || !strings.Contains(warnings[0], tt.wantWarning)) {
+				t.Errorf("warnings = %q, want one containing %q", warnings, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestValidateYAMLWithoutLegacySchemas(t *testing.T) {
+	// Без WithLegacySchemas ключ version не влияет на выбор схемы
+	document := "version: \"3.0\"\nservices:\n  web:\n    image: app\n    healthcheck:\n      start_period: 10s\n"
+
+	diagnostics, err := NewComposeParser().ValidateYAML([]byte(document))
+	if err != nil {
+		t.Fatalf("ValidateYAML: %v", err)
+	}
+	if len(diagnostics) != 0 {
+		t.Errorf("diagnostics = %v, want none", diagnostics)
+	}
+}
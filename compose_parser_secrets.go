@@ -0,0 +1,79 @@
+package compose_parser
+
+import (
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseServiceFileReferences парсит подключение секретов или конфигураций к сервису
+// в короткой (имя) или длинной (source, target, uid, gid, mode) форме
+func (p *ComposeParser) parseServiceFileReferences(node *yaml.Node, path string) ([]ServiceFileReference, error) {
+	var references []ServiceFileReference
+	err := forEachItem(node, path, func(item *yaml.Node, path string) error {
+		if item.Kind != yaml.MappingNode {
+			source, err := decodeString(item, path)
+			if err != nil {
+				return err
+			}
+			if source == "" {
+				return invalidValueError(item, path, "source must not be empty")
+			}
+			references = append(references, ServiceFileReference{Source: source})
+			return nil
+		}
+
+		d := p.newNodeDecoder(item, path)
+		reference := ServiceFileReference{
+			Source: d.stringField("source"),
+			Target: d.stringField("target"),
+			UID:    d.stringField("uid"),
+			GID:    d.stringField("gid"),
+		}
+		d.field("mode", func(node *yaml.Node, path string) error {
+			mode, err := p.decodeFileMode(node, path)
+			if err == nil && !isNullNode(node) && !p.isUnresolved(node) {
+				reference.Mode = &mode
+			}
+			return err
+		})
+		if err := d.Err(); err != nil {
+			return err
+		}
+		if reference.Source == "" {
+			return requiredFieldError(item, path, "source")
+		}
+		references = append(references, reference)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return references, nil
+}
+
+// decodeFileMode декодирует права доступа к файлу. Строковое значение ("0440")
+// читается как восьмеричное, числовое - как записано в YAML (0440 и 0o440 - восьмеричные)
+func (p *ComposeParser) decodeFileMode(node *yaml.Node, path string) (uint32, error) {
+	if isNullNode(node) || p.isUnresolved(node) {
+		return 0, nil
+	}
+	if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!str" {
+		mode, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(node.Value), "0o"), 8, 32)
+		if err != nil {
+			return 0, invalidValueError(node, path, "invalid file mode %q, expected an octal number", node.Value)
+		}
+		return uint32(mode), nil
+	}
+
+	mode, err := p.decodeInt(node, path)
+	if err != nil {
+		return 0, err
+	}
+	if mode < 0 || mode > 0o7777 {
+		return 0, invalidValueError(node, path, "file mode %o is out of range", mode)
+	}
+	return uint32(mode), nil
+}
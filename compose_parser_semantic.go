@@ -0,0 +1,293 @@
+package compose_parser
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// projectValidator проверяет связи между элементами разобранного проекта
+type projectValidator struct {
+	project     *ComposeProjectConfig
+	diagnostics []Diagnostic
+	edges       map[string][]serviceEdge // Зависимости сервисов для поиска циклов
+}
+
+// serviceEdge представляет зависимость сервиса от другого сервиса
+type serviceEdge struct {
+	target string
+	path   string // Путь элемента, задающего зависимость
+}
+
+// ValidateProject проверяет связи между элементами проекта: существование сервисов,
+// сетей, томов, секретов и конфигураций, на которые ссылаются сервисы, циклы
+// зависимостей, одновременное использование network_mode и networks, повторяющиеся
+// container_name, сервисы без image и build, пересечение подсетей и статические
+// адреса вне подсетей сети. Возвращает диагностики с путями и положениями элементов
+func (p *ComposeParser) ValidateProject(project *ComposeProjectConfig) []Diagnostic {
+	v := &projectValidator{
+		project: project,
+		edges:   make(map[string][]serviceEdge),
+	}
+
+	services := p.getSortedServices(project.Services, project.ServiceOrder)
+	for _, item := range services {
+		v.validateService(item.name, item.service)
+	}
+	for _, item := range services {
+		v.checkCycles(item.name, nil, make(map[string]bool))
+	}
+	v.checkContainerNames(services)
+	v.checkSubnets(p.getSortedNetworks(project.Networks))
+
+	return sortDiagnostics(v.diagnostics)
+}
+
+// validateService проверяет ссылки одного сервиса
+func (v *projectValidator) validateService(name string, service *ComposeServiceConfig) {
+	path := "services." + name
+
+	// Сервис, который не удалось разобрать, уже имеет диагностику
+	if service.Status == "error" && service.Image == "" && service.Build == nil {
+		return
+	}
+
+	if service.Image == "" && service.Build == nil {
+		v.report(SeverityError, newValidationError(ErrCodeRequiredField, nil, path,
+			"service has neither an image nor a build context specified"))
+	}
+
+	for i, dependency := range service.DependsOn {
+		dependencyPath := v.elementPath(path+".depends_on", dependency.Service, i)
+		if _, ok := v.project.Services[dependency.Service]; !ok {
+			// Отсутствие необязательной зависимости не мешает запуску сервиса
+			severity := SeverityError
+			if !dependency.Required {
+				severity = SeverityWarning
+			}
+			v.report(severity, newReferenceError(ErrCodeUndefinedReference, nil, dependencyPath, "service", dependency.Service,
+				"depends on undefined service %q", dependency.Service))
+			continue
+		}
+		v.addEdge(name, dependency.Service, dependencyPath)
+	}
+
+	if target, ok := strings.CutPrefix(service.NetworkMode, "service:"); ok {
+		v.serviceReference(name, target, path+".network_mode", "network_mode")
+	}
+	if service.NetworkMode != "" && len(service.Networks) > 0 {
+		v.report(SeverityError, newValidationError(ErrCodeConflictingResource, nil, path+".network_mode",
+			"network_mode and networks cannot be combined"))
+	}
+
+	for i, network := range service.Networks {
+		networkPath := v.elementPath(path+".networks", network.Name, i)
+		definition, ok := v.project.Networks[network.Name]
+		if !ok {
+			if network.Name != "default" {
+				v.report(SeverityError, newReferenceError(ErrCodeUndefinedReference, nil, networkPath,
+					"network", network.Name, "service refers to undefined network %q", network.Name))
+			}
+			continue
+		}
+		v.checkStaticAddress(networkPath+".ipv4_address", network.IPv4Address, network.Name, definition)
+		v.checkStaticAddress(networkPath+".ipv6_address", network.IPv6Address, network.Name, definition)
+	}
+
+	for i, volume := range service.Volumes {
+		if !volume.IsNamed() {
+			continue
+		}
+		if _, ok := v.project.Volumes[volume.Source]; ok {
+			continue
+		}
+		v.report(SeverityError, newReferenceError(ErrCodeUndefinedReference, nil, indexPath(path+".volumes", i),
+			"volume", volume.Source, "service refers to undefined volume %q", volume.Source))
+	}
+
+	v.checkSecrets(path+".secrets", service.Secrets)
+	if service.Build != nil {
+		v.checkSecrets(path+".build.secrets", service.Build.Secrets)
+	}
+	for i, config := range service.Configs {
+		if _, ok := v.project.Configs[config.Source]; !ok {
+			v.report(SeverityError, newReferenceError(ErrCodeUndefinedReference, nil, indexPath(path+".configs", i),
+				"config", config.Source, "service refers to undefined config %q", config.Source))
+		}
+	}
+
+	for i, source := range service.VolumesFrom {
+		// container:name ссылается на контейнер вне проекта
+		if strings.HasPrefix(source, "container:") {
+			continue
+		}
+		target, _, _ := strings.Cut(source, ":")
+		v.serviceReference(name, target, indexPath(path+".volumes_from", i), "volumes_from")
+	}
+}
+
+// checkSecrets проверяет, что секреты, подключенные к сервису или сборке, объявлены в проекте
+func (v *projectValidator) checkSecrets(path string, secrets []ServiceFileReference) {
+	for i, secret := range secrets {
+		if _, ok := v.project.Secrets[secret.Source]; !ok {
+			v.report(SeverityError, newReferenceError(ErrCodeUndefinedReference, nil, indexPath(path, i),
+				"secret", secret.Source, "service refers to undefined secret %q", secret.Source))
+		}
+	}
+}
+
+// serviceReference проверяет ссылку на сервис и добавляет зависимость
+func (v *projectValidator) serviceReference(name, target, path, field string) {
+	if _, ok := v.project.Services[target]; !ok {
+		v.report(SeverityError, newReferenceError(ErrCodeUndefinedReference, nil, path, "service", target,
+			"%s refers to undefined service %q", field, target))
+		return
+	}
+	v.addEdge(name, target, path)
+}
+
+// addEdge добавляет зависимость сервиса
+func (v *projectValidator) addEdge(from, to, path string) {
+	v.edges[from] = append(v.edges[from], serviceEdge{target: to, path: path})
+}
+
+// checkCycles ищет циклы зависимостей, проходящие через начальный сервис стека. Обход
+// не заходит в сервисы с именем меньше начального, поэтому каждый цикл сообщается
+// один раз - при обходе из сервиса с наименьшим именем в цикле
+func (v *projectValidator) checkCycles(name string, stack []string, visiting map[string]bool) {
+	stack = append(stack, name)
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	for _, edge := range v.edges[name] {
+		if edge.target == stack[0] {
+			cycle := append(append([]string{}, stack...), edge.target)
+			v.report(SeverityError, newReferenceError(ErrCodeCircularReference, nil, edge.path, "service", edge.target,
+				"dependency cycle detected: %s", strings.Join(cycle, " -> ")))
+			continue
+		}
+		if visiting[edge.target] || edge.target < stack[0] {
+			continue
+		}
+		v.checkCycles(edge.target, stack, visiting)
+	}
+}
+
+// checkContainerNames проверяет, что имена контейнеров не повторяются
+func (v *projectValidator) checkContainerNames(services []serviceWithOrder) {
+	owners := make(map[string]string)
+	for _, item := range services {
+		containerName := item.service.ContainerName
+		if containerName == "" {
+			continue
+		}
+		if owner, ok := owners[containerName]; ok {
+			v.report(SeverityError, newValidationError(ErrCodeConflictingResource, nil, "services."+item.name+".container_name",
+				"container name %q is already in use by service %q", containerName, owner))
+			continue
+		}
+		owners[containerName] = item.name
+	}
+}
+
+// checkSubnets проверяет, что подсети сетей проекта не пересекаются. Подсети внешних
+// сетей не известны и не проверяются
+func (v *projectValidator) checkSubnets(networks []networkWithName) {
+	type subnet struct {
+		prefix  netip.Prefix
+		network string
+	}
+
+	var subnets []subnet
+	for _, item := range networks {
+		if item.network.External || item.network.IPAM == nil {
+			continue
+		}
+		for i, pool := range item.network.IPAM.Config {
+			prefix, err := netip.ParsePrefix(pool.Subnet)
+			if err != nil {
+				continue
+			}
+			for _, other := range subnets {
+				if other.prefix.Overlaps(prefix) {
+					v.report(SeverityError, newValidationError(ErrCodeConflictingResource, nil,
+						indexPath("networks."+item.name+".ipam.config", i)+".subnet",
+						"subnet %s overlaps with subnet %s of network %q", pool.Subnet, other.prefix, other.network))
+					break
+				}
+			}
+			subnets = append(subnets, subnet{prefix: prefix.Masked(), network: item.name})
+		}
+	}
+}
+
+// checkStaticAddress проверяет, что статический адрес сервиса принадлежит одной
+// из подсетей сети того же семейства адресов
+func (v *projectValidator) checkStaticAddress(path, value, networkName string, network *NetworkConfig) {
+	if value == "" {
+		return
+	}
+	address, err := netip.ParseAddr(value)
+	if err != nil {
+		v.report(SeverityError, invalidValueError(nil, path, "%q is not a valid IP address", value))
+		return
+	}
+	if network.External {
+		return
+	}
+
+	var subnets []netip.Prefix
+	if network.IPAM != nil {
+		for _, pool := range network.IPAM.Config {
+			prefix, err := netip.ParsePrefix(pool.Subnet)
+			if err == nil && prefix.Addr().Is4() == address.Is4() {
+				subnets = append(subnets, prefix)
+			}
+		}
+	}
+	if len(subnets) == 0 {
+		v.report(SeverityError, invalidValueError(nil, path,
+			"static address %s requires network %q to declare a subnet in ipam.config", value, networkName))
+		return
+	}
+	for _, subnet := range subnets {
+		if subnet.Contains(address) {
+			return
+		}
+	}
+	v.report(SeverityError, invalidValueError(nil, path,
+		"address %s is outside of the subnets of network %q", value, networkName))
+}
+
+// elementPath возвращает путь элемента, заданного списком или отображением:
+// services.web.networks.front или services.web.networks[0]
+func (v *projectValidator) elementPath(path, name string, index int) string {
+	if _, ok := v.project.Locations[joinPath(path, name)]; ok {
+		return joinPath(path, name)
+	}
+	return indexPath(path, index)
+}
+
+// report добавляет диагностику, дополняя ошибку положением элемента по его пути
+func (v *projectValidator) report(severity string, err ComposeError) {
+	details := err.Details()
+	if details.Location == nil {
+		details.Location, _ = v.project.LocationOf(details.Path)
+	}
+	v.diagnostics = append(v.diagnostics, newDiagnostic(severity, err))
+}
+
+// semanticDiagnostics выполняет ValidateProject для разобранного проекта. Без режима
+// WithErrorTolerance первая ошибка возвращается как ошибка разбора
+func (p *ComposeParser) semanticDiagnostics(project *ComposeProjectConfig) error {
+	diagnostics := p.ValidateProject(project)
+	if !p.tolerant {
+		for _, diagnostic := range diagnostics {
+			if diagnostic.Severity == SeverityError {
+				return diagnostic.Err
+			}
+		}
+	}
+
+	project.Diagnostics = sortDiagnostics(append(project.Diagnostics, diagnostics...))
+	return nil
+}
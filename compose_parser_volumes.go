@@ -0,0 +1,251 @@
+package compose_parser
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseVolumeMounts парсит монтирования томов
+func (p *ComposeParser) parseVolumeMounts(ctx *parseContext, node *yaml.Node, path string) ([]VolumeMount, error) {
+	var volumes []VolumeMount
+
+	err := forEachItem(node, path, func(item *yaml.Node, path string) error {
+		volume, err := p.parseVolumeMount(item, path)
+		if err != nil {
+			return err
+		}
+		volume.Location = ctx.locationAt(path)
+		volumes = append(volumes, *volume)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return volumes, nil
+}
+
+// parseVolumeMount парсит одно монтирование тома
+func (p *ComposeParser) parseVolumeMount(node *yaml.Node, path string) (*VolumeMount, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		volume, err := p.parseVolumeString(node.Value)
+		if err != nil {
+			return nil, invalidValueError(node, path, "%v", err)
+		}
+		return volume, nil
+	case yaml.MappingNode:
+		return p.parseVolumeMap(node, path)
+	default:
+		return nil, newTypeError(node, path, "a string or a mapping")
+	}
+}
+
+// parseVolumeString парсит короткую форму монтирования: [source:]target[:options].
+// Путь без источника создает анонимный том
+func (p *ComposeParser) parseVolumeString(spec string) (*VolumeMount, error) {
+	parts := splitVolumeSpec(spec)
+
+	volume := &VolumeMount{Type: "volume"}
+	switch len(parts) {
+	case 1:
+		volume.Target = parts[0]
+	case 2:
+		volume.Source, volume.Target = parts[0], parts[1]
+	case 3:
+		volume.Source, volume.Target = parts[0], parts[1]
+		if err := applyVolumeOptions(volume, parts[2]); err != nil {
+			return nil, fmt.Errorf("invalid volume format %q: %v", spec, err)
+		}
+	default:
+		return nil, fmt.Errorf("invalid volume format %q: too many colons", spec)
+	}
+
+	if volume.Target == "" {
+		return nil, fmt.Errorf("invalid volume format %q: empty target", spec)
+	}
+
+	switch {
+	case volume.Source == "":
+		// Анонимный том
+	case isNamedPipe(volume.Source):
+		volume.Type = "npipe"
+	case isHostPath(volume.Source):
+		volume.Type = "bind"
+		// В короткой форме отсутствующий путь хоста создается автоматически
+		if volume.Bind == nil {
+			volume.Bind = &VolumeBindOptions{}
+		}
+		volume.Bind.CreateHostPath = true
+	}
+
+	if volume.Volume != nil && volume.Type != "volume" {
+		return nil, fmt.Errorf("invalid volume format %q: nocopy is only supported for volumes", spec)
+	}
+
+	return volume, nil
+}
+
+// splitVolumeSpec разбивает короткую форму монтирования по двоеточиям,
+// не разделяя букву диска в путях Windows (C:\data)
+func splitVolumeSpec(spec string) []string {
+	var parts []string
+	var current strings.Builder
+
+	for i := 0; i < len(spec); i++ {
+		c := spec[i]
+		if c == ':' {
+			isDrive := current.Len() == 1 && isDriveLetter(current.String()[0]) &&
+				i+1 < len(spec) && (spec[i+1] == '\\' || spec[i+1] == '/')
+			if !isDrive {
+				parts = append(parts, current.String())
+				current.Reset()
+				continue
+			}
+		}
+		current.WriteByte(c)
+	}
+
+	return append(parts, current.String())
+}
+
+// applyVolumeOptions применяет параметры короткой формы монтирования (ro,z,nocopy...)
+func applyVolumeOptions(volume *VolumeMount, options string) error {
+	for _, option := range strings.Split(options, ",") {
+		switch option {
+		case "ro":
+			volume.ReadOnly = true
+		case "rw":
+			volume.ReadOnly = false
+		case "z", "Z":
+			if volume.Bind == nil {
+				volume.Bind = &VolumeBindOptions{}
+			}
+			volume.Bind.SELinux = option
+		case "shared", "rshared", "slave", "rslave", "private", "rprivate":
+			if volume.Bind == nil {
+				volume.Bind = &VolumeBindOptions{}
+			}
+			volume.Bind.Propagation = option
+		case "nocopy":
+			volume.Volume = &VolumeVolumeOptions{NoCopy: true}
+		case "consistent", "cached", "delegated":
+			volume.Consistency = option
+		default:
+			return fmt.Errorf("unknown option %q", option)
+		}
+	}
+	return nil
+}
+
+// parseVolumeMap парсит полную форму монтирования
+func (p *ComposeParser) parseVolumeMap(node *yaml.Node, path string) (*VolumeMount, error) {
+	volume := &VolumeMount{
+		Type: "volume", // значение по умолчанию
+	}
+
+	d := p.newNodeDecoder(node, path)
+
+	d.field("type", func(node *yaml.Node, path string) (err error) {
+		volume.Type, err = decodeString(node, path)
+		if err != nil {
+			return err
+		}
+		switch volume.Type {
+		case "volume", "bind", "tmpfs", "npipe", "image", "cluster":
+			return nil
+		default:
+			return invalidValueError(node, path, "invalid volume type %s", volume.Type)
+		}
+	})
+
+	volume.Source = d.stringField("source")
+	volume.Target = d.stringField("target")
+	volume.ReadOnly = d.boolField("read_only")
+	volume.Consistency = d.stringField("consistency")
+
+	d.field("bind", func(node *yaml.Node, path string) error {
+		bind := &VolumeBindOptions{}
+		options := p.newNodeDecoder(node, path)
+		bind.Propagation = options.stringField("propagation")
+		bind.CreateHostPath = options.boolField("create_host_path")
+		options.field("selinux", func(node *yaml.Node, path string) (err error) {
+			bind.SELinux, err = decodeString(node, path)
+			if err == nil && bind.SELinux != "z" && bind.SELinux != "Z" {
+				err = invalidValueError(node, path, "invalid selinux option %s, expected z or Z", bind.SELinux)
+			}
+			return err
+		})
+		volume.Bind = bind
+		return options.Err()
+	})
+
+	d.field("volume", func(node *yaml.Node, path string) error {
+		options := p.newNodeDecoder(node, path)
+		volume.Volume = &VolumeVolumeOptions{
+			NoCopy:  options.boolField("nocopy"),
+			Subpath: options.stringField("subpath"),
+		}
+		return options.Err()
+	})
+
+	d.field("tmpfs", func(node *yaml.Node, path string) error {
+		tmpfs := &VolumeTmpfsOptions{}
+		options := p.newNodeDecoder(node, path)
+		// Размер задается числом байт или строкой с единицами измерения
+		tmpfs.Size = options.stringField("size")
+		// Права доступа задаются по тем же правилам, что и для secrets и configs
+		options.field("mode", func(node *yaml.Node, path string) (err error) {
+			tmpfs.Mode, err = p.decodeFileMode(node, path)
+			return err
+		})
+		volume.Tmpfs = tmpfs
+		return options.Err()
+	})
+
+	d.field("image", func(node *yaml.Node, path string) error {
+		options := p.newNodeDecoder(node, path)
+		volume.Image = &VolumeImageOptions{
+			Subpath: options.stringField("subpath"),
+		}
+		return options.Err()
+	})
+
+	if err := d.Err(); err != nil {
+		return nil, err
+	}
+
+	if volume.Target == "" {
+		return nil, requiredFieldError(node, path, "target")
+	}
+	// Путь хоста и именованный канал не имеют значения по умолчанию
+	if volume.Source == "" && (volume.Type == "bind" || volume.Type == "npipe") {
+		return nil, requiredFieldError(node, path, "source")
+	}
+
+	return volume, nil
+}
+
+// isHostPath проверяет, является ли источник путем на хосте, а не именем тома
+func isHostPath(source string) bool {
+	if strings.HasPrefix(source, "/") || strings.HasPrefix(source, ".") || strings.HasPrefix(source, "~") {
+		return true
+	}
+	// Путь Windows: C:\data, C:/data или UNC путь \\server\share
+	if strings.HasPrefix(source, `\\`) {
+		return true
+	}
+	return len(source) >= 3 && isDriveLetter(source[0]) && source[1] == ':' && (source[2] == '\\' || source[2] == '/')
+}
+
+// isNamedPipe проверяет, является ли источник именованным каналом Windows
+func isNamedPipe(source string) bool {
+	return strings.HasPrefix(source, `\\.\pipe\`) || strings.HasPrefix(source, `//./pipe/`)
+}
+
+// isDriveLetter проверяет, является ли символ буквой диска Windows
+func isDriveLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
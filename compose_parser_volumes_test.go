@@ -0,0 +1,190 @@
+package compose_parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseVolumeString(t *testing.T) {
+	createHostPath := &VolumeBindOptions{CreateHostPath: true}
+
+	tests := []struct {
+		spec string
+		want VolumeMount
+	}{
+		{"/data", VolumeMount{Type: "volume", Target: "/data"}},
+		{"data:/data", VolumeMount{Type: "volume", Source: "data", Target: "/data"}},
+		{"data:/data:ro", VolumeMount{Type: "volume", Source: "data", Target: "/data", ReadOnly: true}},
+		{"data:/data:rw,nocopy", VolumeMount{Type: "volume", Source: "data", Target: "/data", Volume: &VolumeVolumeOptions{NoCopy: true}}},
+		{"./data:/data", VolumeMount{Type: "bind", Source: "./data", Target: "/data", Bind: createHostPath}},
+		{"../data:/data:cached", VolumeMount{Type: "bind", Source: "../data", Target: "/data", Consistency: "cached", Bind: createHostPath}},
+		{"/srv/data:/data:ro,z", VolumeMount{Type: "bind", Source: "/srv/data", Target: "/data", ReadOnly: true,
+			Bind: &VolumeBindOptions{CreateHostPath: true, SELinux: "z"}}},
+		{"/srv:/srv:rshared", VolumeMount{Type: "bind", Source: "/srv", Target: "/srv",
+			Bind: &VolumeBindOptions{CreateHostPath: true, Propagation: "rshared"}}},
+		{"~/config:/config", VolumeMount{Type: "bind", Source: "~/config", Target: "/config", Bind: createHostPath}},
+		{`C:\data:/data`, VolumeMount{Type: "bind", Source: `C:\data`, Target: "/data", Bind: createHostPath}},
+		{`C:/data:C:\data:ro`, VolumeMount{Type: "bind", Source: "C:/data", Target: `C:\data`, ReadOnly: true, Bind: createHostPath}},
+		{`\\server\share:/share`, VolumeMount{Type: "bind", Source: `\\server\share`, Target: "/share", Bind: createHostPath}},
+		{`\\.\pipe\docker_engine:\\.\pipe\docker_engine`, VolumeMount{Type: "npipe", Source: `\\.\pipe\docker_engine`, Target: `\\.\pipe\docker_engine`}},
+	}
+
+	parser := NewComposeParser()
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := parser.parseVolumeString(tt.spec)
+			if err != nil {
+				t.Fatalf("parseVolumeString(%q): %v", tt.spec, err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("parseVolumeString(%q) = %+v, want %+v", tt.spec, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseVolumeStringErrors(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr string
+	}{
+		{"data:", "empty target"},
+		{"a:b:ro:extra", "too many colons"},
+		{"data:/data:bogus", `unknown option "bogus"`},
+		{"./data:/data:nocopy", "nocopy is only supported for volumes"},
+	}
+
+	parser := NewComposeParser()
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := parser.parseVolumeString(tt.spec)
+			if err == nil {
+				t.Fatalf("parseVolumeString(%q): expected an error", tt.spec)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseVolumeString(%q) error = %q, want it to contain %q", tt.spec, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplitVolumeSpec(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []string
+	}{
+		{"/data", []string{"/data"}},
+		{"data:/data:ro", []string{"data", "/data", "ro"}},
+		{`C:\data:/data`, []string{`C:\data`, "/data"}},
+		{`C:\data:D:\data:ro`, []string{`C:\data`, `D:\data`, "ro"}},
+		{"c:/data:/data", []string{"c:/data", "/data"}},
+		{"a:b", []string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			if got := splitVolumeSpec(tt.spec); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitVolumeSpec(%q) = %q, want %q", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseVolumesLongSyntax(t *testing.T) {
+	tests := []struct {
+		name    string
+		volume  string
+		want    VolumeMount
+		wantErr string
+	}{
+		{
+			name:   "default type",
+			volume: "source: data\n        target: /data",
+			want:   VolumeMount{Type: "volume", Source: "data", Target: "/data"},
+		},
+		{
+			name:   "bind options",
+			volume: "type: bind\n        source: ./data\n        target: /data\n        read_only: true\n        bind:\n          propagation: rslave\n          create_host_path: true\n          selinux: Z",
+			want: VolumeMount{Type: "bind", Source: "./data", Target: "/data", ReadOnly: true,
+				Bind: &VolumeBindOptions{Propagation: "rslave", CreateHostPath: true, SELinux: "Z"}},
+		},
+		{
+			name:   "volume options",
+			volume: "type: volume\n        source: data\n        target: /data\n        volume:\n          nocopy: true\n          subpath: sub",
+			want:   VolumeMount{Type: "volume", Source: "data", Target: "/data", Volume: &VolumeVolumeOptions{NoCopy: true, Subpath: "sub"}},
+		},
+		{
+			name:   "tmpfs octal number mode",
+			volume: "type: tmpfs\n        target: /tmp\n        tmpfs:\n          size: 64m\n          mode: 0o1777",
+			want:   VolumeMount{Type: "tmpfs", Target: "/tmp", Tmpfs: &VolumeTmpfsOptions{Size: "64m", Mode: 0o1777}},
+		},
+		{
+			name:   "tmpfs octal string mode",
+			volume: "type: tmpfs\n        target: /tmp\n        tmpfs:\n          size: 1024\n          mode: \"1777\"",
+			want:   VolumeMount{Type: "tmpfs", Target: "/tmp", Tmpfs: &VolumeTmpfsOptions{Size: "1024", Mode: 0o1777}},
+		},
+		{
+			name:   "image options",
+			volume: "type: image\n        source: alpine\n        target: /image\n        image:\n          subpath: etc",
+			want:   VolumeMount{Type: "image", Source: "alpine", Target: "/image", Image: &VolumeImageOptions{Subpath: "etc"}},
+		},
+		{
+			name:   "anonymous volume",
+			volume: "type: volume\n        target: /data",
+			want:   VolumeMount{Type: "volume", Target: "/data"},
+		},
+		{name: "missing target", volume: "source: data", wantErr: "target is required"},
+		{name: "bind without source", volume: "type: bind\n        target: /data", wantErr: "source is required"},
+		{name: "npipe without source", volume: "type: npipe\n        target: /pipe", wantErr: "source is required"},
+		{name: "invalid type", volume: "type: disk\n        target: /data", wantErr: "invalid volume type"},
+		{name: "invalid selinux", volume: "type: bind\n        source: .\n        target: /data\n        bind:\n          selinux: x", wantErr: "invalid selinux option"},
+		{name: "tmpfs mode out of range", volume: "type: tmpfs\n        target: /tmp\n        tmpfs:\n          mode: 0o17777", wantErr: "out of range"},
+		{name: "tmpfs invalid mode string", volume: "type: tmpfs\n        target: /tmp\n        tmpfs:\n          mode: \"rwx\"", wantErr: "invalid file mode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := "services:\n  web:\n    image: app\n    volumes:\n      - " + tt.volume + "\n"
+			project, err := NewComposeParser().ParseYAML([]byte(document))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseYAML: %v", err)
+			}
+
+			got := project.Services["web"].Volumes[0]
+			got.Location = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("volume = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVolumeMountKinds(t *testing.T) {
+	tests := []struct {
+		spec                   string
+		anonymous, named, bind bool
+	}{
+		{"/data", true, false, false},
+		{"data:/data", false, true, false},
+		{"./data:/data", false, false, true},
+		{`C:\data:/data`, false, false, true},
+	}
+
+	parser := NewComposeParser()
+	for _, tt := range tests {
+		volume, err := parser.parseVolumeString(tt.spec)
+		if err != nil {
+			t.Fatalf("parseVolumeString(%q): %v", tt.spec, err)
+		}
+		if volume.IsAnonymous() != tt.anonymous || volume.IsNamed() != tt.named || volume.IsBind() != tt.bind {
+			t.Errorf("%q: anonymous=%v named=%v bind=%v", tt.spec, volume.IsAnonymous(), volume.IsNamed(), volume.IsBind())
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package compose_parser
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// mappingValue возвращает значение ключа в узле-отображении
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue заменяет значение ключа или добавляет новый ключ в конец отображения
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content, newScalarNode(key), value)
+}
+
+// deleteMappingValue удаляет ключ из отображения
+func deleteMappingValue(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// newScalarNode создает строковый скалярный узел
+func newScalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// copyNode создает глубокую копию узла
+func copyNode(node *yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	clone := *node
+	if len(node.Content) > 0 {
+		clone.Content = make([]*yaml.Node, len(node.Content))
+		for i, child := range node.Content {
+			clone.Content[i] = copyNode(child)
+		}
+	}
+	return &clone
+}
+
+// expandNode возвращает копию дерева, в которой алиасы заменены копиями якорей,
+// а ключи слияния "<<" раскрыты в обычные ключи отображения
+func expandNode(node *yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+
+	switch node.Kind {
+	case yaml.AliasNode:
+		return expandNode(node.Alias)
+
+	case yaml.DocumentNode, yaml.SequenceNode:
+		clone := *node
+		clone.Content = make([]*yaml.Node, len(node.Content))
+		for i, child := range node.Content {
+			clone.Content[i] = expandNode(child)
+		}
+		return &clone
+
+	case yaml.MappingNode:
+		clone := *node
+		clone.Content = make([]*yaml.Node, 0, len(node.Content))
+
+		// Явно заданные ключи имеют приоритет над ключами слияния
+		explicit := make(map[string]bool)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if !isMergeKey(node.Content[i]) {
+				explicit[node.Content[i].Value] = true
+			}
+		}
+
+		added := make(map[string]bool)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode := node.Content[i]
+			valueNode := node.Content[i+1]
+
+			if !isMergeKey(keyNode) {
+				clone.Content = append(clone.Content, copyNode(keyNode), expandNode(valueNode))
+				continue
+			}
+
+			// Значением ключа слияния может быть отображение или список отображений,
+			// при этом более ранние отображения списка имеют приоритет
+			sources := []*yaml.Node{valueNode}
+			if resolved := resolveAlias(valueNode); resolved.Kind == yaml.SequenceNode {
+				sources = resolved.Content
+			}
+			for _, source := range sources {
+				merged := expandNode(source)
+				if merged == nil || merged.Kind != yaml.MappingNode {
+					continue
+				}
+				for j := 0; j+1 < len(merged.Content); j += 2 {
+					key := merged.Content[j].Value
+					if explicit[key] || added[key] {
+						continue
+					}
+					added[key] = true
+					clone.Content = append(clone.Content, merged.Content[j], merged.Content[j+1])
+				}
+			}
+		}
+		return &clone
+
+	default:
+		clone := *node
+		return &clone
+	}
+}
+
+// resolveAlias возвращает узел, на который указывает алиас
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// isMergeKey проверяет, является ли узел ключом слияния "<<"
+func isMergeKey(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Value == "<<" && (node.Tag == "" || node.Tag == "!!merge" || node.Tag == "tag:yaml.org,2002:merge")
+}